@@ -4,50 +4,287 @@
 package argon2id
 
 import (
+	"context"
 	"crypto/subtle"
 	"encoding/base64"
-	"errors"
 	"fmt"
+	"hash/crc32"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/argon2"
 )
 
 var (
+	// ErrCorruptKey is matched using errors.Is by every error returned because
+	// the provided argon2 key can not be parsed or decoded, that is by
+	// ErrInvalidKeyFormat and its children and by ErrChecksumMismatch. It
+	// tells corrupt stored keys apart from wrong passwords.
+	ErrCorruptKey = newError("argon2id: corrupt argon2 key.")
+
+	// ErrInvalidKeyFormat is matched using errors.Is by every error returned
+	// because the provided argon2 key is malformed, e.g. ErrInvalidKeyLength,
+	// ErrInvalidVersion, ErrInvalidParameters or ErrInvalidBase64. It tells
+	// malformed keys apart from wrong passwords and matches ErrCorruptKey.
+	ErrInvalidKeyFormat = newChildError("argon2id: invalid argon2 key format.", ErrCorruptKey)
+
 	// ErrPasswordRequired is returned by HashPassword or VerifyPassword if no
 	// password was provided.
-	ErrPasswordRequired = errors.New("argon2id: password must not be empty.")
+	ErrPasswordRequired = newError("argon2id: password must not be empty.")
 
 	// ErrSaltRequired is returned by HashPassword if no salt was provided.
-	ErrSaltRequired = errors.New("argon2id: salt must not be empty.")
+	ErrSaltRequired = newError("argon2id: salt must not be empty.")
 
 	// ErrArgon2KeyRequired is returned by VerifyPassword if no argon2 key was
 	// provided.
-	ErrArgon2KeyRequired = errors.New("argon2id: argon2 key must not be empty.")
+	ErrArgon2KeyRequired = newChildError("argon2id: argon2 key must not be empty.", ErrInvalidKeyFormat)
 
 	// ErrInvalidKeyLength is returned by VerifyPassword if the provided argon2
 	// key is of invalid length.
-	ErrInvalidKeyLength = errors.New("argon2id: argon2 key invalid length.")
+	ErrInvalidKeyLength = newChildError("argon2id: argon2 key invalid length.", ErrInvalidKeyFormat)
+
+	// ErrArgonVersionMismatch is matched using errors.Is by the *VersionError
+	// returned by VerifyPassword if the provided argon2 key version is
+	// different than the one used by the package.
+	ErrArgonVersionMismatch = newError("argon2id: argon2 key version mismatch.")
+
+	// ErrInvalidVersion is returned by VerifyPassword if the version of the
+	// provided argon2 key can not be parsed.
+	ErrInvalidVersion = newChildError("argon2id: argon2 key invalid version.", ErrInvalidKeyFormat)
+
+	// ErrInvalidParameters is returned by VerifyPassword if the m, t and p
+	// parameters of the provided argon2 key can not be parsed.
+	ErrInvalidParameters = newChildError("argon2id: argon2 key invalid parameters.", ErrInvalidKeyFormat)
+
+	// ErrUnsupportedVariant is returned by VerifyPassword if the provided key
+	// uses the argon2i or argon2d variant.
+	ErrUnsupportedVariant = newError("argon2id: argon2 key uses unsupported variant.")
 
-	// ErrArgonVersionMismatch is returned by VerifyPassword if the provided
-	// argon2 key version is different than the one used by the package.
-	ErrArgonVersionMismatch = errors.New("argon2id: argon2 key version mismatch.")
+	// ErrWrongAlgorithm is returned by VerifyPassword if the provided key is
+	// not an argon2 key.
+	ErrWrongAlgorithm = newChildError("argon2id: key is not an argon2 key.", ErrInvalidKeyFormat)
 
 	// ErrHashNotEqualPassword is returned by VerifyPassword if the provided
 	// hash does not equal the password.
-	ErrHashNotEqualPassword = errors.New("argon2id: hash not equal password.")
+	ErrHashNotEqualPassword = newError("argon2id: hash not equal password.")
+
+	// ErrContextRequired is returned by HashPasswordWithContext or
+	// VerifyPasswordWithContext if no context was provided.
+	ErrContextRequired = newError("argon2id: context must not be empty.")
+
+	// ErrUnknownEncoding is returned by DetectEncoding if the salt and hash of
+	// the provided argon2 key are neither valid RawURLEncoding nor valid
+	// RawStdEncoding.
+	ErrUnknownEncoding = newError("argon2id: argon2 key has unknown base64 encoding.")
+
+	// ErrChecksumMismatch is returned by VerifyPassword if the provided argon2
+	// key carries a checksum that does not match the rest of the key. It
+	// matches ErrCorruptKey.
+	ErrChecksumMismatch = newChildError("argon2id: argon2 key checksum mismatch.", ErrCorruptKey)
+
+	// ErrKeyNotJSONString is returned by UnmarshalKeyJSON if the provided JSON
+	// value is not a string.
+	ErrKeyNotJSONString = newError("argon2id: argon2 key must be a json string.")
+
+	// ErrNeedsRehash is returned by VerifyOrRehash if the password matches the
+	// argon2 key but the key was created with outdated options. The password
+	// is still valid.
+	ErrNeedsRehash = newError("argon2id: argon2 key needs rehash.")
+
+	// ErrInvalidOptions is returned if the provided options can not be used
+	// with argon2.
+	ErrInvalidOptions = newError("argon2id: invalid options.")
+
+	// ErrInvalidTime is returned by Options.Validate if Time is smaller than
+	// one. It matches ErrInvalidOptions using errors.Is.
+	ErrInvalidTime = newChildError("argon2id: time must be at least 1.", ErrInvalidOptions)
+
+	// ErrInvalidMemory is returned by Options.Validate if Memory is smaller
+	// than 8 KiB per thread. It matches ErrInvalidOptions using errors.Is.
+	ErrInvalidMemory = newChildError("argon2id: memory must be at least 8 KiB per thread.", ErrInvalidOptions)
+
+	// ErrInvalidThreads is returned by Options.Validate if Threads is smaller
+	// than one. It matches ErrInvalidOptions using errors.Is.
+	ErrInvalidThreads = newChildError("argon2id: threads must be at least 1.", ErrInvalidOptions)
+
+	// ErrInvalidKeyLen is returned by Options.Validate if KeyLen is not
+	// between 16 and 128 bytes. It matches ErrInvalidOptions using errors.Is.
+	ErrInvalidKeyLen = newChildError("argon2id: key length must be between 16 and 128 bytes.", ErrInvalidOptions)
+
+	// ErrInvalidHashLength is returned by VerifyPassword if the hash of the
+	// provided argon2 key is not between 16 and 128 bytes long. A short hash
+	// would make the comparison trivial to brute-force.
+	ErrInvalidHashLength = newChildError("argon2id: argon2 key hash must be between 16 and 128 bytes.", ErrInvalidKeyFormat)
+
+	// ErrSecretRequired is returned if no server key or pepper was provided.
+	ErrSecretRequired = newError("argon2id: secret must not be empty.")
+
+	// ErrInvalidHMACKeyLen is returned by HashPasswordHMAC or
+	// VerifyPasswordHMAC if the key length exceeds the HMAC-SHA256 output.
+	ErrInvalidHMACKeyLen = newError("argon2id: key length must not exceed 32 bytes when using hmac.")
+
+	// ErrInvalidDeriveLength is returned by VerifyThenDerive or DeriveNumbered
+	// if the requested length is zero or, for VerifyThenDerive, exceeds what
+	// HKDF-SHA256 can produce.
+	ErrInvalidDeriveLength = newError("argon2id: invalid derive length.")
+
+	// ErrInvalidCount is returned by DeriveNumbered if the provided count is
+	// smaller than one.
+	ErrInvalidCount = newError("argon2id: count must be at least one.")
+
+	// ErrUnknownStandard is returned by Options.MeetsStandard if the provided
+	// standard is not known.
+	ErrUnknownStandard = newError("argon2id: unknown standard.")
+
+	// ErrInvalidHexSalt is returned by VerifyPasswordHexSalt if the provided
+	// salt is not valid hex.
+	ErrInvalidHexSalt = newError("argon2id: salt is not valid hex.")
+
+	// ErrVerifyTooExpensive is returned by VerifyPasswordBounded if verifying
+	// the provided argon2 key is estimated to take longer than allowed.
+	ErrVerifyTooExpensive = newError("argon2id: argon2 key too expensive to verify.")
+
+	// ErrExceedsLimits is returned by VerifyPasswordLimited if the options of
+	// the provided argon2 key exceed the configured limits.
+	ErrExceedsLimits = newError("argon2id: argon2 key exceeds limits.")
+
+	// ErrKeyLenMismatch is returned by VerifyPasswordKeyLen if the hash of the
+	// provided argon2 key does not have the expected length.
+	ErrKeyLenMismatch = newError("argon2id: argon2 key hash length mismatch.")
+
+	// ErrTooManyThreads is returned by Options.ValidateForHost if Threads
+	// exceeds the number of CPUs of the current host. The options still work
+	// but the threads contend for the CPUs.
+	ErrTooManyThreads = newError("argon2id: threads exceed the number of CPUs.")
+
+	// ErrRehashFailed is wrapped by the rehash error of VerifyAndUpgradeBytes
+	// if the password matches the argon2 key but the new key could not be
+	// created. The password is still valid in that case. The wrapping error
+	// describes the cause.
+	ErrRehashFailed = newError("argon2id: rehash failed.")
+
+	// ErrInvalidBinaryKey is returned by DecodeBinary or VerifyBinary if the
+	// provided binary key is malformed.
+	ErrInvalidBinaryKey = newChildError("argon2id: invalid binary argon2 key.", ErrInvalidKeyFormat)
+
+	// ErrInvalidPercentage is returned by NewCohortHasher if the provided
+	// rollout percentage is not between 0 and 100.
+	ErrInvalidPercentage = newError("argon2id: percentage must be between 0 and 100.")
+
+	// ErrInvalidSaltLength is returned by GenerateSalt if the provided length
+	// is not positive.
+	ErrInvalidSaltLength = newError("argon2id: salt length must be positive.")
+
+	// ErrInvalidBase64 is matched using errors.Is by the error returned by
+	// VerifyPassword or ParseKey if the salt or hash of the provided argon2 key
+	// is not valid base64. The error unwraps to the base64 error.
+	ErrInvalidBase64 = newChildError("argon2id: argon2 key invalid base64.", ErrInvalidKeyFormat)
+
+	// ErrPasswordTooLong is returned by HashPassword or VerifyPassword if the
+	// provided password is longer than MaxPasswordLength.
+	ErrPasswordTooLong = newError("argon2id: password too long.")
+
+	// ErrWrappedKey is returned by VerifyPassword and every other function
+	// comparing a password to an argon2 key if the key was created by
+	// WrapExistingHash and must be verified using VerifyExistingHash.
+	ErrWrappedKey = newError("argon2id: argon2 key wraps an existing hash.")
+
+	// ErrInvalidEncoding is returned by DecodeBase64String or VerifyPassword if
+	// the provided base64 is padded. Keys use base64 without padding, padded
+	// segments are written by some external tools. It matches
+	// ErrInvalidBase64.
+	ErrInvalidEncoding = newChildError("argon2id: base64 must not be padded.", ErrInvalidBase64)
+
+	// ErrSaltTooShort is returned by HashPassword if the provided salt is
+	// shorter than Options.MinSaltLen.
+	ErrSaltTooShort = newError("argon2id: salt too short.")
+
+	// ErrUnsupportedOption is returned by functions that can not honour a field
+	// of the provided options, e.g. HashPasswordJSON if Options.Secret is set,
+	// instead of silently ignoring it. It matches ErrInvalidOptions using
+	// errors.Is.
+	ErrUnsupportedOption = newChildError("argon2id: option not supported.", ErrInvalidOptions)
+
+	// ErrPaddedEncoding is returned by Options.Validate or ReEncode if the
+	// provided base64 encoding pads its output. VerifyPassword rejects padded
+	// keys with ErrInvalidEncoding, so they must never be written. It matches
+	// ErrInvalidOptions using errors.Is.
+	ErrPaddedEncoding = newChildError("argon2id: encoding must not use padding.", ErrInvalidOptions)
+)
+
+// MaxPasswordLength is the maximum length in bytes of the passwords accepted
+// by HashPassword, VerifyPassword and the other hash and verify functions of
+// the package. argon2 processes the whole password, so without a limit a huge
+// password submitted to a login endpoint costs the server accordingly. Zero
+// disables the limit. HashReader is not limited as it is meant for large
+// inputs.
+var MaxPasswordLength = 1024
+
+// Version is the argon2 version implemented by golang.org/x/crypto/argon2 and
+// written to every key, 0x13 (19). Keys of the older version 0x10 (16) can not
+// be verified by VerifyPassword. VerifyPasswordVersions can be used to accept
+// them anyway, they are then verified using version 0x13.
+const Version = argon2.Version
+
+// minKeyLen and maxKeyLen are the bounds of the hash length of keys in bytes.
+const (
+	minKeyLen = 16
+	maxKeyLen = 128
 )
 
 // DefaultOptions contains sane defaults as of December 2021. These defaults
 // are subject to change if new recommendations are released. These settings
 // were chosen for usage in a web application.
 var DefaultOptions = &Options{
-	Time:    1,
-	Memory:  64 * 1024,
-	Threads: 4,
-	KeyLen:  32,
+	Time:       1,
+	Memory:     64 * 1024,
+	Threads:    4,
+	KeyLen:     32,
+	MinSaltLen: 8,
 }
 
+// OptionsInteractive, OptionsModerate and OptionsSensitive follow the presets
+// of libsodium. They all use a single thread, so the cost does not depend on
+// the number of cores.
+var (
+	// OptionsInteractive uses 64 MiB and 2 passes. It is meant for interactive
+	// logins where latency matters.
+	OptionsInteractive = &Options{
+		Time:       2,
+		Memory:     64 * 1024,
+		Threads:    1,
+		KeyLen:     32,
+		MinSaltLen: 8,
+	}
+
+	// OptionsModerate uses 256 MiB and 3 passes, which takes about a second on
+	// a typical server. It is a tradeoff between interactive and sensitive.
+	OptionsModerate = &Options{
+		Time:       3,
+		Memory:     256 * 1024,
+		Threads:    1,
+		KeyLen:     32,
+		MinSaltLen: 8,
+	}
+
+	// OptionsSensitive uses 1 GiB and 4 passes, which takes several seconds.
+	// It is meant for rarely derived keys protecting highly sensitive data,
+	// e.g. in batch jobs, not for logins.
+	OptionsSensitive = &Options{
+		Time:       4,
+		Memory:     1024 * 1024,
+		Threads:    1,
+		KeyLen:     32,
+		MinSaltLen: 8,
+	}
+)
+
+// now returns the current time. It is used wherever the package records or
+// reads time and is only meant to be overridden by tests. It is internal and
+// not part of the API.
+var now = time.Now
+
 // Options contain all the options that can be set using the argon2id
 // algorithm.
 type Options struct {
@@ -55,99 +292,817 @@ type Options struct {
 	Memory  uint32
 	Threads uint8
 	KeyLen  uint32
+
+	// Checksum appends a CRC-32 checksum of the key as a trailing "$crc="
+	// segment. VerifyPassword checks it before running argon2, so corrupted
+	// keys can be told apart from wrong passwords. Keys without a checksum are
+	// standard PHC strings.
+	Checksum bool
+
+	// Nonce appends a random "$n=" segment to every key, so two keys of the
+	// same password, salt and options are distinguishable, e.g. in a cache.
+	// The nonce does not affect the hash itself and is ignored by
+	// VerifyPassword.
+	Nonce bool
+
+	// Encoding is the base64 encoding of the salt and hash of new keys. It
+	// defaults to base64.RawURLEncoding if nil. base64.RawStdEncoding produces
+	// the PHC strings expected by the argon2 CLI and most other libraries.
+	// VerifyPassword accepts both.
+	Encoding *base64.Encoding
+
+	// Secret is an optional server side secret, also called pepper, so a
+	// leaked database alone is not enough to brute-force the keys. If set, the
+	// password is replaced by HMAC-SHA256(Secret, password) before it is
+	// passed to argon2. The secret is not part of the key, keys created with a
+	// secret must be verified using VerifyPasswordWithSecret or a Hasher.
+	Secret []byte
+
+	// Version is the argon2 version written to new keys. It defaults to
+	// argon2.Version if zero. golang.org/x/crypto/argon2 only implements
+	// version 0x13, so the hash is always computed using it and keys tagged
+	// with another version are rejected by VerifyPassword with a
+	// *VersionError. It is meant for testing the handling of future versions.
+	Version int
+
+	// MinSaltLen is the minimum length in bytes of the salts passed to
+	// HashPassword, which returns ErrSaltTooShort for shorter salts. The salt
+	// is measured as given, base64 encoded salts are not decoded. It is 8 in
+	// DefaultOptions and the presets, zero disables the check.
+	MinSaltLen uint32
+
+	// Label mixes a purpose into the derivation, so the same password and
+	// salt produce unrelated hashes for different labels, e.g. "login" and
+	// "vault". argon2 is run on uint32_be(len(Label)) || Label || password,
+	// the construction of HashPasswordWithContext, after Secret is applied.
+	// Unlike the context of HashPasswordWithContext the label is stored in
+	// the key as a trailing "$label=" segment holding its RawURLEncoding, so
+	// VerifyPassword applies it again. It is not secret. An empty label
+	// leaves the password unchanged.
+	Label string
+
+	// wrapped is set for options parsed from keys created by
+	// WrapExistingHash. verifyKeyBytes refuses to compare passwords to them,
+	// only VerifyExistingHash clears it.
+	wrapped bool
+}
+
+// Validate reports whether the options can be used with argon2. It checks the
+// minimums of the argon2 specification, Time and Threads must be at least 1
+// and Memory at least 8 KiB per thread, and that KeyLen is between 16 and 128
+// bytes, the range of hash lengths accepted by VerifyPassword. Encoding must
+// not use padding. The returned errors match ErrInvalidOptions using
+// errors.Is.
+func (o *Options) Validate() error {
+	if o == nil {
+		return ErrInvalidOptions
+	}
+
+	if o.Time < 1 {
+		return ErrInvalidTime
+	}
+
+	if o.Threads < 1 {
+		return ErrInvalidThreads
+	}
+
+	if o.Memory < 8*uint32(o.Threads) {
+		return ErrInvalidMemory
+	}
+
+	if o.KeyLen < minKeyLen || o.KeyLen > maxKeyLen {
+		return ErrInvalidKeyLen
+	}
+
+	if o.Encoding != nil && usesPadding(o.Encoding) {
+		return ErrPaddedEncoding
+	}
+
+	return nil
+}
+
+// checkSaltLength returns ErrSaltTooShort if the given salt is shorter than
+// MinSaltLen.
+func (o *Options) checkSaltLength(salt []byte) error {
+	if uint32(len(salt)) < o.MinSaltLen {
+		return ErrSaltTooShort
+	}
+
+	return nil
+}
+
+// optionsOrDefault returns the given options or DefaultOptions if they are nil.
+// Every function taking options uses it, as do the Options methods describing
+// them, e.g. MemoryHuman, so nil options never panic. Validate rejects nil
+// options with ErrInvalidOptions instead.
+func optionsOrDefault(o *Options) *Options {
+	if o == nil {
+		return DefaultOptions
+	}
+
+	return o
+}
+
+// encoding returns the base64 encoding of new keys created with the options.
+// Padding is removed from padded encodings, so keys encoded without validating
+// the options, e.g. by EncodeKey, can still be verified.
+func (o *Options) encoding() *base64.Encoding {
+	if o.Encoding == nil {
+		return base64.RawURLEncoding
+	}
+
+	if usesPadding(o.Encoding) {
+		return o.Encoding.WithPadding(base64.NoPadding)
+	}
+
+	return o.Encoding
+}
+
+// version returns the argon2 version of new keys or argon2.Version if none is
+// set.
+func (o *Options) version() int {
+	if o.Version == 0 {
+		return argon2.Version
+	}
+
+	return o.Version
+}
+
+// checksumPrefix is the prefix of the optional trailing checksum segment.
+const checksumPrefix = "crc="
+
+// noncePrefix is the prefix of the optional trailing nonce segment.
+const noncePrefix = "n="
+
+// nonceLength is the length in bytes of the random nonce of a key.
+const nonceLength = 12
+
+// labelPrefix is the prefix of the optional trailing segment holding the
+// Options.Label of a key.
+const labelPrefix = "label="
+
+// keyIDPrefix is the prefix of the optional trailing key management id
+// segment appended by some enterprise encoders.
+const keyIDPrefix = "keyid="
+
+// layeredPrefix is the prefix of the optional trailing segment marking keys
+// created by WrapExistingHash.
+const layeredPrefix = "layered="
+
+// trailingPrefixes are the prefixes of the optional segments that may follow
+// the hash segment of a key, before the checksum.
+var trailingPrefixes = []string{noncePrefix, labelPrefix, keyIDPrefix, layeredPrefix}
+
+// checksum returns the hex encoded CRC-32 checksum of the given string.
+func checksum(s string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(s)))
 }
 
 // EncodeToBase64String is a helper function that turns the given bytes into
 // a base64 encoded string.
 func EncodeToBase64String(b []byte) string {
-	return base64.RawURLEncoding.EncodeToString(b)
+	return EncodeToBase64StringWith(b, base64.RawURLEncoding)
+}
+
+// EncodeToBase64StringWith works like EncodeToBase64String but uses the given
+// encoding, e.g. the Options.Encoding of a key. A nil encoding uses
+// base64.RawURLEncoding.
+func EncodeToBase64StringWith(b []byte, enc *base64.Encoding) string {
+	if enc == nil {
+		enc = base64.RawURLEncoding
+	}
+
+	return enc.EncodeToString(b)
 }
 
 // DecodeBase64String is a helper function that decodes the given base64 string.
+// It returns ErrInvalidEncoding if the string is padded.
 func DecodeBase64String(s string) ([]byte, error) {
-	return base64.RawURLEncoding.DecodeString(s)
+	return DecodeBase64StringWith(s, base64.RawURLEncoding)
+}
+
+// DecodeBase64StringWith works like DecodeBase64String but uses the given
+// encoding. A nil encoding uses base64.RawURLEncoding. ErrInvalidEncoding is
+// only returned for padded strings if the encoding does not use padding.
+func DecodeBase64StringWith(s string, enc *base64.Encoding) ([]byte, error) {
+	if enc == nil {
+		enc = base64.RawURLEncoding
+	}
+
+	if isPadded(s) && !usesPadding(enc) {
+		return nil, ErrInvalidEncoding
+	}
+
+	return enc.DecodeString(s)
+}
+
+// isPadded reports whether the given base64 string ends with padding.
+func isPadded(s string) bool {
+	return strings.HasSuffix(s, "=")
+}
+
+// usesPadding reports whether the given encoding pads its output.
+func usesPadding(enc *base64.Encoding) bool {
+	return enc.EncodedLen(1) == 4
+}
+
+// DecodeBase64StringCompat works like DecodeBase64String but falls back to
+// the standard base64 alphabet used by the PHC reference implementation and
+// most other argon2 libraries if the string is not valid RawURLEncoding.
+func DecodeBase64StringCompat(s string) ([]byte, error) {
+	b, err := DecodeBase64String(s)
+	if err == ErrInvalidEncoding {
+		return nil, err
+	}
+
+	if err != nil {
+		return DecodeBase64StringWith(s, base64.RawStdEncoding)
+	}
+
+	return b, nil
+}
+
+// trimKey removes surrounding whitespace from the given argon2 key. Keys read
+// from files exported on Windows end with "\r\n", which would otherwise end up
+// in the hash segment and break its base64 decoding.
+func trimKey(key string) string {
+	return strings.TrimSpace(key)
+}
+
+// splitKey splits the given argon2 key into its segments. The first segment
+// is always empty as the key starts with a "$". Surrounding whitespace is
+// ignored. A trailing checksum segment is validated and removed, as are the
+// optional segments following the hash.
+func splitKey(key string) ([]string, error) {
+	segments, _, err := splitKeyTrailing(key)
+	return segments, err
+}
+
+// splitKeyTrailing works like splitKey but also returns the optional segments
+// following the hash.
+func splitKeyTrailing(key string) ([]string, []string, error) {
+	key = trimKey(key)
+	if key == "" {
+		return nil, nil, ErrArgon2KeyRequired
+	}
+
+	segments := strings.Split(key, "$")
+
+	if last := segments[len(segments)-1]; strings.HasPrefix(last, checksumPrefix) {
+		if last[len(checksumPrefix):] != checksum(key[:strings.LastIndex(key, "$")]) {
+			return nil, nil, ErrChecksumMismatch
+		}
+
+		segments = segments[:len(segments)-1]
+	}
+
+	if len(segments) < 6 {
+		return nil, nil, ErrInvalidKeyLength
+	}
+
+	for _, segment := range segments[6:] {
+		if !isTrailingSegment(segment) {
+			return nil, nil, ErrInvalidKeyLength
+		}
+	}
+
+	return segments[:6], segments[6:], nil
+}
+
+// isTrailingSegment reports whether the given segment is one of the optional
+// segments that may follow the hash.
+func isTrailingSegment(segment string) bool {
+	for _, prefix := range trailingPrefixes {
+		if strings.HasPrefix(segment, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newTrailingSegments returns the optional segments following the hash of a
+// new key created with the given options.
+func newTrailingSegments(options *Options) ([]string, error) {
+	if !options.Nonce {
+		return options.labelSegments(), nil
+	}
+
+	nonce, err := generateSalt(nonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{noncePrefix + nonce}, options.labelSegments()...), nil
 }
 
 // HashPassword takes a password and a salt and returns an argon2 key that
-// can be saved in a database.
+// can be saved in a database. Nil options are treated as DefaultOptions. It
+// returns the error of Options.Validate if the options can not be used with
+// argon2.
 func HashPassword(password string, salt string, options *Options) (string, error) {
-	if password == "" {
-		return "", ErrPasswordRequired
+	if err := checkPasswordLength(len(password)); err != nil {
+		return "", err
+	}
+
+	b := []byte(password)
+	defer wipe(b)
+
+	return HashPasswordBytes(b, []byte(salt), options)
+}
+
+// HashPasswordBytes works like HashPassword but takes the password as a byte
+// slice, so the caller can wipe it after use. The password is neither copied
+// nor modified.
+func HashPasswordBytes(password []byte, salt []byte, options *Options) (string, error) {
+	if err := checkPasswordLength(len(password)); err != nil {
+		return "", err
+	}
+
+	_, key, err := deriveKeyBytes(password, salt, options)
+	return key, err
+}
+
+// checkPasswordLength returns ErrPasswordTooLong if a password of the given
+// length in bytes is longer than MaxPasswordLength.
+func checkPasswordLength(length int) error {
+	if MaxPasswordLength > 0 && length > MaxPasswordLength {
+		return ErrPasswordTooLong
 	}
 
-	if salt == "" {
-		return "", ErrSaltRequired
+	return nil
+}
+
+// deriveKeyBytes derives the hash of the password and returns it together with
+// the argon2 key containing it. The extra segments are appended after the
+// optional segments of the options.
+func deriveKeyBytes(password []byte, salt []byte, options *Options, extra ...string) ([]byte, string, error) {
+	if len(password) == 0 {
+		return nil, "", ErrPasswordRequired
+	}
+
+	if len(salt) == 0 {
+		return nil, "", ErrSaltRequired
+	}
+
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := options.checkSaltLength(salt); err != nil {
+		return nil, "", err
+	}
+
+	trailing, err := newTrailingSegments(options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	trailing = append(trailing, extra...)
+
+	if len(options.Secret) > 0 {
+		password = pepperPasswordBytes(options.Secret, password)
+		defer wipe(password)
+	}
+
+	if options.Label != "" {
+		password = contextPasswordBytes(options.Label, password)
+		defer wipe(password)
 	}
 
 	hash := argon2.IDKey(
-		[]byte(password), []byte(salt),
+		password, salt,
 		options.Time, options.Memory, options.Threads, options.KeyLen,
 	)
 
-	b64Salt := EncodeToBase64String([]byte(salt))
-	b64Hash := EncodeToBase64String(hash)
+	return hash, encodeKey(options, salt, hash, trailing...), nil
+}
+
+// HashPasswordContext works like HashPassword but returns ctx.Err() if ctx is
+// done before the hash is complete. argon2 can not be interrupted, so the hash
+// keeps being computed in the background and its memory stays allocated until
+// it is complete, only the caller is released early.
+func HashPasswordContext(ctx context.Context, password string, salt string, options *Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	type result struct {
+		key string
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		key, err := HashPassword(password, salt, options)
+		done <- result{key, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.key, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// encodeKey returns the argon2id key for the given options, salt and hash. The
+// trailing segments are appended after the hash.
+func encodeKey(options *Options, salt []byte, hash []byte, trailing ...string) string {
+	return encodeVariantKey("argon2id", options, salt, hash, trailing...)
+}
+
+// encodeVariantKey works like encodeKey but writes the given argon2 variant.
+func encodeVariantKey(variant string, options *Options, salt []byte, hash []byte, trailing ...string) string {
+	options = optionsOrDefault(options)
+	b64Salt := options.encoding().EncodeToString(salt)
+	b64Hash := options.encoding().EncodeToString(hash)
 
 	key := fmt.Sprintf(
-		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		argon2.Version, options.Memory, options.Time, options.Threads, b64Salt, b64Hash,
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		variant, options.version(), options.Memory, options.Time, options.Threads, b64Salt, b64Hash,
 	)
 
-	return key, nil
+	for _, segment := range trailing {
+		key += "$" + segment
+	}
+
+	if options.Checksum {
+		key += "$" + checksumPrefix + checksum(key)
+	}
+
+	return key
 }
 
-// VerifyPassword takes a password and an argon2 key and compares both. It will
-// return an error if they are not equal.
-func VerifyPassword(password string, key string) error {
-	if password == "" {
-		return ErrPasswordRequired
+// parseKey parses the given argon2 key and returns the options it was created
+// with, its salt and its hash. The key length of the options is taken from the
+// length of the hash. Salt and hash are decoded using DecodeBase64StringCompat,
+// so keys of other libraries using the standard base64 alphabet are accepted.
+func parseKey(key string) (*Options, []byte, []byte, error) {
+	return parseKeyWithEncoding(key, nil)
+}
+
+// parseKeyWithEncoding works like parseKey but decodes the salt and hash using
+// the given encoding. A nil encoding decodes them like parseKey.
+func parseKeyWithEncoding(key string, encoding *base64.Encoding) (*Options, []byte, []byte, error) {
+	return parseKeyWithVersions(key, encoding, []int{argon2.Version})
+}
+
+// parseKeyWithVersions works like parseKeyWithEncoding but accepts keys of any
+// of the given versions.
+func parseKeyWithVersions(key string, encoding *base64.Encoding, accepted []int) (*Options, []byte, []byte, error) {
+	if err := checkVariant(key); err != nil {
+		return nil, nil, nil, err
+	}
+
+	decodedKey, version, p, err := parseSegments(key)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
+	if !containsVersion(accepted, version) {
+		return nil, nil, nil, &VersionError{Version: version}
+	}
+
+	salt, hash, err := decodeSegments(decodedKey, encoding)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := checkHashLength(hash); err != nil {
+		return nil, nil, nil, err
+	}
+
+	p.KeyLen = uint32(len(hash))
+
+	return p, salt, hash, nil
+}
+
+// checkHashLength returns ErrInvalidHashLength if the length of the given hash
+// is not between minKeyLen and maxKeyLen.
+func checkHashLength(hash []byte) error {
+	if len(hash) < minKeyLen || len(hash) > maxKeyLen {
+		return ErrInvalidHashLength
+	}
+
+	return nil
+}
+
+// containsVersion reports whether the version is one of the accepted ones.
+func containsVersion(accepted []int, version int) bool {
+	for _, v := range accepted {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkVariant returns ErrUnsupportedVariant if the given argon2 key uses
+// another argon2 variant than argon2id and ErrWrongAlgorithm if it is not an
+// argon2 key at all. Empty keys are left to the parser.
+func checkVariant(key string) error {
+	key = trimKey(key)
 	if key == "" {
-		return ErrArgon2KeyRequired
+		return nil
 	}
 
-	decodedKey := strings.Split(key, "$")
-	if len(decodedKey) != 6 {
-		return ErrInvalidKeyLength
+	id := ""
+	if segments := strings.SplitN(key, "$", 3); len(segments) > 1 {
+		id = segments[1]
 	}
 
+	switch id {
+	case "argon2id":
+		return nil
+	case "argon2i", "argon2d":
+		return ErrUnsupportedVariant
+	}
+
+	return ErrWrongAlgorithm
+}
+
+// parseSegments splits the given argon2 key and parses its version and
+// parameters. The version is not checked against the one used by the package.
+// The salt and hash segments are returned undecoded, the label is read from
+// its trailing segment.
+func parseSegments(key string) ([]string, int, *Options, error) {
+	decodedKey, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	version, err := parseVersion(decodedKey[2])
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	p, err := parseParameters(decodedKey[3])
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if p.Label, err = parseLabel(trailing); err != nil {
+		return nil, 0, nil, err
+	}
+
+	p.wrapped = containsSegment(trailing, layeredSegment)
+
+	return decodedKey, version, p, nil
+}
+
+// decodeSegments decodes the salt and hash segments of a split argon2 key
+// using the given encoding. A nil encoding decodes them using
+// DecodeBase64StringCompat. If either segment is padded it returns
+// ErrInvalidEncoding. If either segment can not be decoded it returns an error
+// matching ErrInvalidBase64 that unwraps to the base64 error.
+func decodeSegments(decodedKey []string, encoding *base64.Encoding) ([]byte, []byte, error) {
+	if isPadded(decodedKey[4]) || isPadded(decodedKey[5]) {
+		return nil, nil, ErrInvalidEncoding
+	}
+
+	decode := DecodeBase64StringCompat
+	if encoding != nil {
+		decode = encoding.DecodeString
+	}
+
+	salt, err := decode(decodedKey[4])
+	if err != nil {
+		return nil, nil, withCause(ErrInvalidBase64, err)
+	}
+
+	hash, err := decode(decodedKey[5])
+	if err != nil {
+		return nil, nil, withCause(ErrInvalidBase64, err)
+	}
+
+	return salt, hash, nil
+}
+
+// parseParameters parses the parameter segment of an argon2 key. The m, t and
+// p parameters must each be present exactly once but may appear in any order.
+func parseParameters(segment string) (*Options, error) {
 	p := Options{}
-	version := argon2.Version
+	seen := map[string]bool{}
+
+	for _, parameter := range strings.Split(segment, ",") {
+		name, value := parameter, ""
+		if i := strings.IndexByte(parameter, '='); i >= 0 {
+			name, value = parameter[:i], parameter[i+1:]
+		}
+
+		if seen[name] {
+			return nil, ErrInvalidParameters
+		}
+
+		seen[name] = true
+
+		var bitSize int
+		switch name {
+		case "m", "t":
+			bitSize = 32
+		case "p":
+			bitSize = 8
+		default:
+			return nil, ErrInvalidParameters
+		}
+
+		v, err := strconv.ParseUint(value, 10, bitSize)
+		if err != nil {
+			return nil, ErrInvalidParameters
+		}
+
+		switch name {
+		case "m":
+			p.Memory = uint32(v)
+		case "t":
+			p.Time = uint32(v)
+		case "p":
+			p.Threads = uint8(v)
+		}
+	}
+
+	if len(seen) != 3 {
+		return nil, ErrInvalidParameters
+	}
 
-	if _, err := fmt.Sscanf(decodedKey[2], "v=%d", &version); err != nil {
+	// argon2 panics if time or threads are zero.
+	if p.Time < 1 || p.Threads < 1 {
+		return nil, ErrInvalidParameters
+	}
+
+	return &p, nil
+}
+
+// parseVersion parses the version segment of an argon2 key. The version may be
+// written in decimal ("v=19") or in 0x prefixed hexadecimal ("v=0x13").
+func parseVersion(segment string) (int, error) {
+	if !strings.HasPrefix(segment, "v=") {
+		return 0, ErrInvalidVersion
+	}
+
+	value, base := segment[len("v="):], 10
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		value, base = value[len("0x"):], 16
+	}
+
+	version, err := strconv.ParseUint(value, base, 32)
+	if err != nil {
+		return 0, ErrInvalidVersion
+	}
+
+	return int(version), nil
+}
+
+// VerifyPassword takes a password and an argon2 key and compares both. It will
+// return an error if they are not equal. The returned error is
+// ErrHashNotEqualPassword if the password is wrong, an error matching
+// ErrCorruptKey if the key can not be parsed or decoded, an error matching
+// ErrArgonVersionMismatch if the key has another argon2 version, or one of
+// ErrUnsupportedVariant, ErrPasswordRequired, ErrPasswordTooLong and
+// ErrWrappedKey, so failures can be classified using errors.Is.
+//
+// The copy of the password is taken from a pool. Most of the remaining
+// allocations are made by argon2 itself, which allocates the Memory of the key
+// for every call, so the options of the key dominate the GC pressure of
+// verifying it.
+func VerifyPassword(password string, key string) error {
+	if err := checkPasswordLength(len(password)); err != nil {
 		return err
 	}
 
-	if version != argon2.Version {
-		return ErrArgonVersionMismatch
+	buf, b := copyToBuffer(password)
+	defer releaseBuffer(buf, b)
+
+	return VerifyPasswordBytes(b, key)
+}
+
+// VerifyPasswordBytes works like VerifyPassword but takes the password as a
+// byte slice, so the caller can wipe it after use. The password is neither
+// copied nor modified.
+func VerifyPasswordBytes(password []byte, key string) error {
+	if len(password) == 0 {
+		return ErrPasswordRequired
 	}
 
-	if _, err := fmt.Sscanf(decodedKey[3], "m=%d,t=%d,p=%d",
-		&p.Memory, &p.Time, &p.Threads,
-	); err != nil {
+	if err := checkPasswordLength(len(password)); err != nil {
 		return err
 	}
 
-	salt, err := DecodeBase64String(decodedKey[4])
+	k, err := DecodeKey(key)
 	if err != nil {
 		return err
 	}
 
-	hash, err := DecodeBase64String(decodedKey[5])
+	defer wipe(k.Salt, k.Hash)
+
+	return verifyKeyBytes(password, &k.Options, k.Salt, k.Hash)
+}
+
+// VerifyPasswordWithSecret works like VerifyPassword but verifies keys created
+// with the given Options.Secret. An empty secret behaves like VerifyPassword.
+func VerifyPasswordWithSecret(password string, key string, secret []byte) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if len(secret) == 0 {
+		return VerifyPassword(password, key)
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	b := pepperPasswordBytes(secret, []byte(password))
+	defer wipe(b)
+
+	return VerifyPasswordBytes(b, key)
+}
+
+// VerifyPasswordVersions works like VerifyPassword but accepts keys of any of
+// the given versions instead of only argon2.Version. It returns
+// a *VersionError if the version of the key is not accepted.
+// golang.org/x/crypto/argon2 only implements version 0x13, so keys of other
+// versions are still verified using version 0x13.
+func VerifyPasswordVersions(password string, key string, accepted []int) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	p, salt, hash, err := parseKeyWithVersions(key, nil, accepted)
 	if err != nil {
 		return err
 	}
 
-	p.KeyLen = uint32(len(hash))
+	defer wipe(salt, hash)
+
+	return verifyKey(password, p, salt, hash)
+}
+
+// verifyKey derives a key from the password using the given options and salt
+// and compares it to the hash. Every verification passes through it or
+// verifyKeyBytes, so both return ErrPasswordTooLong for passwords longer than
+// MaxPasswordLength before argon2 is run.
+func verifyKey(password string, p *Options, salt []byte, hash []byte) error {
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	buf, b := copyToBuffer(password)
+	defer releaseBuffer(buf, b)
+
+	return verifyKeyBytes(b, p, salt, hash)
+}
+
+// verifyKeyBytes works like verifyKey but takes the password as a byte slice.
+// It returns ErrWrappedKey for keys created by WrapExistingHash, so the legacy
+// hash they wrap is never accepted as a password. The derived key is wiped
+// before returning.
+func verifyKeyBytes(password []byte, p *Options, salt []byte, hash []byte) error {
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	if p.wrapped {
+		return ErrWrappedKey
+	}
+
+	if p.Label != "" {
+		password = contextPasswordBytes(p.Label, password)
+		defer wipe(password)
+	}
 
 	control := argon2.IDKey(
-		[]byte(password), []byte(salt),
+		password, salt,
 		p.Time, p.Memory, p.Threads, p.KeyLen,
 	)
 
-	if subtle.ConstantTimeCompare(hash, control) == 1 {
+	defer wipe(control)
+
+	if compareHash(hash, control) {
 		return nil
 	}
 
 	return ErrHashNotEqualPassword
 }
+
+// compareHash reports whether both hashes are equal. It must never be replaced
+// by bytes.Equal. subtle.ConstantTimeCompare returns early if the lengths
+// differ, so if they do a is compared to itself instead and the length check
+// is folded into the result. The comparison time therefore only depends on the
+// length of a, the stored hash, and nothing is allocated.
+func compareHash(a []byte, b []byte) bool {
+	other := b
+	if len(a) != len(b) {
+		other = a
+	}
+
+	equal := subtle.ConstantTimeCompare(a, other)
+	sameLength := subtle.ConstantTimeEq(int32(len(a)), int32(len(b)))
+
+	return equal&sameLength == 1
+}
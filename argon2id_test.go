@@ -2,11 +2,28 @@ package argon2id_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dhenkes/argon2id"
 )
 
+// shortSaltOptions are DefaultOptions without the salt length check, so the
+// pinned vectors using the salt "salt" can still be created.
+var shortSaltOptions = func() *argon2id.Options {
+	options := *argon2id.DefaultOptions
+	options.MinSaltLen = 0
+
+	return &options
+}()
+
 func TestEncodeToBase64String(t *testing.T) {
 	t.Run("NilBytes", func(t *testing.T) {
 		if s := argon2id.EncodeToBase64String(nil); s != "" {
@@ -59,6 +76,96 @@ func TestDecodeBase64String(t *testing.T) {
 			t.Fatal("Did not expect pre-defined bytes.")
 		}
 	})
+
+	t.Run("PaddedString", func(t *testing.T) {
+		if _, err := argon2id.DecodeBase64String("dmFsaWRzdHJpbmc="); err != argon2id.ErrInvalidEncoding {
+			t.Fatal("Expected ErrInvalidEncoding.")
+		}
+
+		if _, err := argon2id.DecodeBase64StringCompat("dmFsaWRzdHJpbmc="); err != argon2id.ErrInvalidEncoding {
+			t.Fatal("Expected ErrInvalidEncoding.")
+		}
+	})
+}
+
+func TestBase64StringWith(t *testing.T) {
+	// 0xfb 0xff encodes to characters that differ between the alphabets.
+	b := []byte{0xfb, 0xff}
+
+	for _, c := range []struct {
+		name    string
+		enc     *base64.Encoding
+		encoded string
+	}{
+		{"Nil", nil, "-_8"},
+		{"RawURLEncoding", base64.RawURLEncoding, "-_8"},
+		{"RawStdEncoding", base64.RawStdEncoding, "+/8"},
+		{"StdEncoding", base64.StdEncoding, "+/8="},
+		{"URLEncoding", base64.URLEncoding, "-_8="},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			if s := argon2id.EncodeToBase64StringWith(b, c.enc); s != c.encoded {
+				t.Fatalf("Expected %q, got %q.", c.encoded, s)
+			}
+
+			decoded, err := argon2id.DecodeBase64StringWith(c.encoded, c.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(decoded, b) {
+				t.Fatal("Expected pre-defined bytes.")
+			}
+		})
+	}
+
+	t.Run("Padding", func(t *testing.T) {
+		if _, err := argon2id.DecodeBase64StringWith("+/8=", base64.RawStdEncoding); err != argon2id.ErrInvalidEncoding {
+			t.Fatal("Expected ErrInvalidEncoding.")
+		}
+
+		if _, err := argon2id.DecodeBase64StringWith("+/8", base64.StdEncoding); err == nil {
+			t.Fatal("Expected error for missing padding.")
+		}
+	})
+}
+
+func TestPaddedKey(t *testing.T) {
+	verify := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+	padded := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA==$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU="
+
+	t.Run("Unpadded", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", verify); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Padded", func(t *testing.T) {
+		err := argon2id.VerifyPassword("password", padded)
+		if err != argon2id.ErrInvalidEncoding {
+			t.Fatal("Expected ErrInvalidEncoding.")
+		}
+
+		if !errors.Is(err, argon2id.ErrInvalidBase64) || !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected error matching ErrInvalidBase64 and ErrInvalidKeyFormat.")
+		}
+
+		if _, err := argon2id.DetectEncoding(padded); err != argon2id.ErrInvalidEncoding {
+			t.Fatal("Expected ErrInvalidEncoding.")
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		err := argon2id.VerifyPassword("password", verify[:len(verify)-1]+"!")
+		if !errors.Is(err, argon2id.ErrInvalidBase64) {
+			t.Fatal("Expected ErrInvalidBase64.")
+		}
+
+		if errors.Is(err, argon2id.ErrInvalidEncoding) {
+			t.Fatal("Did not expect ErrInvalidEncoding.")
+		}
+	})
 }
 
 func TestHashPassword(t *testing.T) {
@@ -78,7 +185,7 @@ func TestHashPassword(t *testing.T) {
 	})
 
 	t.Run("ValidHash", func(t *testing.T) {
-		if h, err := argon2id.HashPassword("password", "salt", argon2id.DefaultOptions); err != nil {
+		if h, err := argon2id.HashPassword("password", "salt", shortSaltOptions); err != nil {
 			t.Fatal(err)
 		} else if h != verify {
 			t.Fatal("Expected pre-defined hash.")
@@ -86,7 +193,7 @@ func TestHashPassword(t *testing.T) {
 	})
 
 	t.Run("InvalidHash", func(t *testing.T) {
-		if h, err := argon2id.HashPassword("password", "salt1", argon2id.DefaultOptions); err != nil {
+		if h, err := argon2id.HashPassword("password", "salt1", shortSaltOptions); err != nil {
 			t.Fatal(err)
 		} else if h == verify {
 			t.Fatal("Did not expext pre-defined hash.")
@@ -123,12 +230,72 @@ func TestVerifyPassword(t *testing.T) {
 			}
 		})
 
+		t.Run("UnsupportedVariant", func(t *testing.T) {
+			for _, variant := range []string{"argon2i", "argon2d"} {
+				if err := argon2id.VerifyPassword("password", strings.Replace(key, "argon2id", variant, 1)); err != argon2id.ErrUnsupportedVariant {
+					t.Fatalf("Expected ErrUnsupportedVariant for %s.", variant)
+				}
+			}
+		})
+
+		t.Run("WrongAlgorithm", func(t *testing.T) {
+			for _, k := range []string{
+				"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+				"$scrypt$ln=16,r=8,p=1$c2FsdA$c2FsdA",
+				"$argon2$v=19$m=65536,t=1,p=4$c2FsdA$c2FsdA",
+				"$ARGON2ID$v=19$m=65536,t=1,p=4$c2FsdA$c2FsdA",
+				"argon2id",
+			} {
+				if err := argon2id.VerifyPassword("password", k); err != argon2id.ErrWrongAlgorithm {
+					t.Fatalf("Expected ErrWrongAlgorithm for %q.", k)
+				}
+			}
+		})
+
+		t.Run("InvalidVersion", func(t *testing.T) {
+			for _, v := range []string{"19", "v=", "v=x13", "v=0x", "v=0xzz", "v=-19"} {
+				if err := argon2id.VerifyPassword("password", "$argon2id$"+v+"$m=65536,t=1,p=4$c2FsdA$c2FsdA"); err != argon2id.ErrInvalidVersion {
+					t.Fatalf("Expected ErrInvalidVersion for %q.", v)
+				}
+			}
+		})
+
+		t.Run("ZeroParameters", func(t *testing.T) {
+			for _, parameters := range []string{"m=65536,t=0,p=4", "m=65536,t=1,p=0"} {
+				if err := argon2id.VerifyPassword("password", strings.Replace(key, "m=65536,t=1,p=4", parameters, 1)); err != argon2id.ErrInvalidParameters {
+					t.Fatalf("Expected ErrInvalidParameters for %q.", parameters)
+				}
+			}
+		})
+
 		t.Run("ValidKey", func(t *testing.T) {
 			if err := argon2id.VerifyPassword("password", key); err != nil {
 				t.Fatal("Did not expext error.")
 			}
 		})
 
+		t.Run("LineEndings", func(t *testing.T) {
+			for _, suffix := range []string{"\r\n", "\n", "\r", " ", "\t\r\n"} {
+				if err := argon2id.VerifyPassword("password", key+suffix); err != nil {
+					t.Fatalf("Expected key with suffix %q to verify: %v", suffix, err)
+				}
+			}
+		})
+
+		t.Run("HexVersion", func(t *testing.T) {
+			for _, v := range []string{"v=0x13", "v=0X13", "v=0x0013"} {
+				if err := argon2id.VerifyPassword("password", strings.Replace(key, "v=19", v, 1)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+
+		t.Run("HexVersionMismatch", func(t *testing.T) {
+			if err := argon2id.VerifyPassword("password", strings.Replace(key, "v=19", "v=0x10", 1)); !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+				t.Fatal("Expected ErrArgonVersionMismatch.")
+			}
+		})
+
 		t.Run("InvalidKey", func(t *testing.T) {
 			if err := argon2id.VerifyPassword("password", key+"1"); err == nil {
 				t.Fatal("Expected error.")
@@ -142,3 +309,876 @@ func TestVerifyPassword(t *testing.T) {
 		})
 	})
 }
+
+func TestHashPasswordContext(t *testing.T) {
+	t.Run("Complete", func(t *testing.T) {
+		key, err := argon2id.HashPasswordContext(context.Background(), "password", "salt", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU" {
+			t.Fatal("Expected pre-defined hash.")
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordContext(context.Background(), "", "salt", argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := argon2id.HashPasswordContext(ctx, "password", "salt", argon2id.DefaultOptions); err != context.Canceled {
+			t.Fatal("Expected context.Canceled.")
+		}
+	})
+
+	t.Run("DeadlineExceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		options := &argon2id.Options{Time: 8, Memory: 32 * 1024, Threads: 1, KeyLen: 32}
+
+		start := time.Now()
+		if _, err := argon2id.HashPasswordContext(ctx, "password", "salt", options); err != context.DeadlineExceeded {
+			t.Fatal("Expected context.DeadlineExceeded.")
+		}
+
+		if time.Since(start) > time.Second {
+			t.Fatal("Expected caller to be released early.")
+		}
+	})
+}
+
+func TestOptionsEncoding(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Encoding: base64.RawStdEncoding}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Format", func(t *testing.T) {
+		if key != "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA" {
+			t.Fatal("Expected pre-defined standard encoded hash.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		o := *options
+		o.Encoding = nil
+
+		key, err := argon2id.HashPassword("password", "salt", &o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA" {
+			t.Fatal("Expected pre-defined url encoded hash.")
+		}
+	})
+}
+
+func TestSecret(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Secret: []byte("secret")}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Construction", func(t *testing.T) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("password"))
+
+		expected, err := argon2id.HashPassword(string(mac.Sum(nil)), "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != expected {
+			t.Fatal("Expected HMAC-SHA256(secret, password) to be hashed.")
+		}
+	})
+
+	t.Run("NotEmbedded", func(t *testing.T) {
+		if strings.Contains(key, "secret") || strings.Contains(key, argon2id.EncodeToBase64String([]byte("secret"))) {
+			t.Fatal("Did not expect the secret in the key.")
+		}
+	})
+
+	t.Run("VerifyPasswordWithSecret", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithSecret("password", key, []byte("secret")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPasswordWithSecret("password1", key, []byte("secret")); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithSecret("password", key, []byte("other")); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if err := argon2id.VerifyPassword("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("Hasher", func(t *testing.T) {
+		h, err := argon2id.NewHasher(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := h.Verify("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestStdEncodingFallback(t *testing.T) {
+	// password:salt, encoded using RawStdEncoding
+	key := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA"
+
+	t.Run("ValidPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("InvalidBase64", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", strings.Replace(key, "YJlbY", "YJ*bY", 1)); !errors.Is(err, argon2id.ErrInvalidBase64) {
+			t.Fatal("Expected ErrInvalidBase64.")
+		}
+	})
+
+	t.Run("DecodeBase64StringCompat", func(t *testing.T) {
+		for _, s := range []string{"-_-_", "+/+/"} {
+			b, err := argon2id.DecodeBase64StringCompat(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, []byte{0xfb, 0xff, 0xbf}) {
+				t.Fatalf("Expected fbffbf for %q.", s)
+			}
+		}
+
+		if _, err := argon2id.DecodeBase64StringCompat("*"); err == nil {
+			t.Fatal("Expected error.")
+		}
+	})
+}
+
+func TestHashLength(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1}
+
+	for _, c := range []struct {
+		name   string
+		keyLen uint32
+		err    error
+	}{
+		{"TooShort", 15, argon2id.ErrInvalidHashLength},
+		{"Minimum", 16, nil},
+		{"Maximum", 128, nil},
+		{"TooLong", 129, argon2id.ErrInvalidHashLength},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			hash := bytes.Repeat([]byte{0xaa}, int(c.keyLen))
+			o := *options
+			o.KeyLen = c.keyLen
+
+			key := argon2id.EncodeKey(&o, []byte("salt"), hash)
+
+			err := argon2id.VerifyPassword("password", key)
+			if c.err == nil && err != argon2id.ErrHashNotEqualPassword {
+				t.Fatalf("Expected ErrHashNotEqualPassword, got %v.", err)
+			}
+
+			if c.err != nil && err != c.err {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+		})
+	}
+
+	t.Run("OneByteHash", func(t *testing.T) {
+		key := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YA"
+		if err := argon2id.VerifyPassword("password", key); !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+	})
+}
+
+func TestNilOptions(t *testing.T) {
+	verify := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("HashPassword", func(t *testing.T) {
+		key, err := argon2id.HashPassword("password", "saltsalt", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := argon2id.HashPassword("password", "saltsalt", argon2id.DefaultOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != expected {
+			t.Fatal("Expected DefaultOptions to be used.")
+		}
+	})
+
+	t.Run("EncodeKey", func(t *testing.T) {
+		_, salt, hash, err := argon2id.ParseKey(verify)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if argon2id.EncodeKey(nil, salt, hash) != verify {
+			t.Fatal("Expected DefaultOptions to be used.")
+		}
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		if rehash, err := argon2id.NeedsRehash(verify, nil); err != nil {
+			t.Fatal(err)
+		} else if rehash {
+			t.Fatal("Expected key using DefaultOptions to be up to date.")
+		}
+	})
+
+	t.Run("VerifyPasswordLimited", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordLimited("password", verify, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestOptionsVersion(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	t.Run("Default", func(t *testing.T) {
+		key, err := argon2id.HashPassword("password", "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA" {
+			t.Fatal("Expected version 19.")
+		}
+	})
+
+	future := *options
+	future.Version = 20
+
+	key, err := argon2id.HashPassword("password", "salt", &future)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Tagged", func(t *testing.T) {
+		if !strings.HasPrefix(key, "$argon2id$v=20$") {
+			t.Fatal("Expected version 20.")
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		var versionErr *argon2id.VersionError
+		if err := argon2id.VerifyPassword("password", key); !errors.As(err, &versionErr) {
+			t.Fatal("Expected VersionError.")
+		} else if versionErr.Version != 20 {
+			t.Fatal("Expected version 20.")
+		}
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		if rehash, err := argon2id.NeedsRehash(key, &future); err != nil {
+			t.Fatal(err)
+		} else if rehash {
+			t.Fatal("Expected key of the target version to be up to date.")
+		}
+
+		if rehash, err := argon2id.NeedsRehash(key, options); err != nil {
+			t.Fatal(err)
+		} else if !rehash {
+			t.Fatal("Expected key of another version to need a rehash.")
+		}
+	})
+}
+
+func TestMaxPasswordLength(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+	long := strings.Repeat("a", argon2id.MaxPasswordLength+1)
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		if argon2id.MaxPasswordLength != 1024 {
+			t.Fatal("Expected default of 1024 bytes.")
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		if _, err := argon2id.HashPassword(long[1:], "salt", options); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("HashPassword", func(t *testing.T) {
+		if _, err := argon2id.HashPassword(long, "salt", options); err != argon2id.ErrPasswordTooLong {
+			t.Fatal("Expected ErrPasswordTooLong.")
+		}
+
+		if _, err := argon2id.HashPasswordBytes([]byte(long), []byte("salt"), options); err != argon2id.ErrPasswordTooLong {
+			t.Fatal("Expected ErrPasswordTooLong.")
+		}
+	})
+
+	t.Run("VerifyPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword(long, key); err != argon2id.ErrPasswordTooLong {
+			t.Fatal("Expected ErrPasswordTooLong.")
+		}
+
+		if err := argon2id.VerifyPasswordWithSecret(long, key, []byte("secret")); err != argon2id.ErrPasswordTooLong {
+			t.Fatal("Expected ErrPasswordTooLong.")
+		}
+	})
+
+	t.Run("OtherVerifiers", func(t *testing.T) {
+		for name, verify := range map[string]func() error{
+			"VerifyPasswordLimited":      func() error { return argon2id.VerifyPasswordLimited(long, key, nil) },
+			"VerifyPasswordConstantTime": func() error { return argon2id.VerifyPasswordConstantTime(long, key) },
+			"VerifyPasswordKeyLen":       func() error { return argon2id.VerifyPasswordKeyLen(long, key, 32) },
+			"VerifyOrRehash":             func() error { return argon2id.VerifyOrRehash(long, key, options) },
+			"VerifyPasswordHMAC":         func() error { return argon2id.VerifyPasswordHMAC(long, key, []byte("server")) },
+			"VerifyPasswordVersions": func() error {
+				return argon2id.VerifyPasswordVersions(long, key, []int{argon2id.Version})
+			},
+			"VerifyAndUpgradeBytes": func() error {
+				_, _, _, err := argon2id.VerifyAndUpgradeBytes([]byte(long), key, &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Secret: []byte("secret")})
+				return err
+			},
+			"VerifyPasswordWithPeppers": func() error {
+				_, err := argon2id.VerifyPasswordWithPeppers(long, key, [][]byte{[]byte("secret")})
+				return err
+			},
+			"VerifyThenDerive": func() error {
+				_, err := argon2id.VerifyThenDerive(long, key, "info", 32)
+				return err
+			},
+		} {
+			if err := verify(); err != argon2id.ErrPasswordTooLong {
+				t.Fatalf("%s: Expected ErrPasswordTooLong, got %v.", name, err)
+			}
+		}
+	})
+
+	t.Run("ConstantTimeMalformedKey", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordConstantTime(long, "invalid"); err != argon2id.ErrPasswordTooLong {
+			t.Fatal("Expected ErrPasswordTooLong.")
+		}
+	})
+
+	t.Run("OtherHashFunctions", func(t *testing.T) {
+		for name, hash := range map[string]func() error{
+			"HashPasswordHMAC": func() error {
+				_, err := argon2id.HashPasswordHMAC(long, "salt", []byte("server"), options)
+				return err
+			},
+			"HashPasswordJSON": func() error {
+				_, err := argon2id.HashPasswordJSON(long, "salt", options)
+				return err
+			},
+			"DeriveNumbered": func() error {
+				_, err := argon2id.DeriveNumbered(long, "salt", 2, 32, options)
+				return err
+			},
+		} {
+			if err := hash(); err != argon2id.ErrPasswordTooLong {
+				t.Fatalf("%s: Expected ErrPasswordTooLong, got %v.", name, err)
+			}
+		}
+	})
+
+	t.Run("HashReader", func(t *testing.T) {
+		if _, err := argon2id.HashReader(strings.NewReader(long), "salt", options); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		defer func(max int) { argon2id.MaxPasswordLength = max }(argon2id.MaxPasswordLength)
+		argon2id.MaxPasswordLength = 0
+
+		longKey, err := argon2id.HashPassword(long, "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword(long, longKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMinSaltLen(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		if _, err := argon2id.HashPassword("password", "salt", argon2id.DefaultOptions); err != argon2id.ErrSaltTooShort {
+			t.Fatal("Expected ErrSaltTooShort.")
+		}
+
+		if _, err := argon2id.HashPassword("password", "saltsalt", argon2id.DefaultOptions); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, MinSaltLen: 16}
+
+		if _, err := argon2id.HashPassword("password", "saltsaltsaltsal", options); err != argon2id.ErrSaltTooShort {
+			t.Fatal("Expected ErrSaltTooShort.")
+		}
+
+		if _, err := argon2id.HashPasswordHMAC("password", "saltsaltsaltsal", []byte("key"), options); err != argon2id.ErrSaltTooShort {
+			t.Fatal("Expected ErrSaltTooShort.")
+		}
+
+		if _, err := argon2id.HashPasswordJSON("password", "saltsaltsaltsal", options); err != argon2id.ErrSaltTooShort {
+			t.Fatal("Expected ErrSaltTooShort.")
+		}
+
+		if _, err := argon2id.HashPassword("password", "saltsaltsaltsalt", options); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		if _, err := argon2id.HashPassword("password", "s", shortSaltOptions); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestPasswordBytes(t *testing.T) {
+	verify := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("HashPasswordBytes", func(t *testing.T) {
+		password := []byte("password")
+
+		key, err := argon2id.HashPasswordBytes(password, []byte("salt"), shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != verify {
+			t.Fatal("Expected pre-defined hash.")
+		}
+
+		if string(password) != "password" {
+			t.Fatal("Expected password to be left untouched.")
+		}
+	})
+
+	t.Run("VerifyPasswordBytes", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordBytes([]byte("password"), verify); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPasswordBytes([]byte("password1"), verify); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordBytes(nil, []byte("salt"), argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+
+		if _, err := argon2id.HashPasswordBytes([]byte("password"), nil, argon2id.DefaultOptions); err != argon2id.ErrSaltRequired {
+			t.Fatal("Expected ErrSaltRequired.")
+		}
+
+		if err := argon2id.VerifyPasswordBytes(nil, verify); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("WipesConversions", func(t *testing.T) {
+		var wiped [][]byte
+		restore := argon2id.SetWipeHook(func(b []byte) {
+			wiped = append(wiped, b)
+		})
+		defer restore()
+
+		if err := argon2id.VerifyPassword("password", verify); err != nil {
+			t.Fatal(err)
+		}
+
+		// control, salt, hash and password copy
+		if len(wiped) != 4 {
+			t.Fatalf("Expected 4 wiped buffers, got %d.", len(wiped))
+		}
+
+		if len(wiped[1]) != 4 || len(wiped[2]) != 32 {
+			t.Fatal("Expected decoded salt and hash to be wiped.")
+		}
+
+		for _, b := range wiped {
+			for _, c := range b {
+				if c != 0 {
+					t.Fatal("Expected buffer to be zeroed.")
+				}
+			}
+		}
+	})
+}
+
+func TestChecksum(t *testing.T) {
+	options := *shortSaltOptions
+	options.Checksum = true
+
+	key, err := argon2id.HashPassword("password", "salt", &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Format", func(t *testing.T) {
+		verify := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+		if !strings.HasPrefix(key, verify+"$crc=") {
+			t.Fatal("Expected pre-defined hash followed by a checksum.")
+		}
+	})
+
+	t.Run("ValidKey", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("CorruptedHash", func(t *testing.T) {
+		corrupted := strings.Replace(key, "OWwm", "OWwn", 1)
+		if err := argon2id.VerifyPassword("password", corrupted); err != argon2id.ErrChecksumMismatch {
+			t.Fatal("Expected ErrChecksumMismatch.")
+		}
+	})
+
+	t.Run("CorruptedParameters", func(t *testing.T) {
+		corrupted := strings.Replace(key, "t=1", "t=2", 1)
+		if err := argon2id.VerifyPassword("password", corrupted); err != argon2id.ErrChecksumMismatch {
+			t.Fatal("Expected ErrChecksumMismatch.")
+		}
+	})
+
+	t.Run("LineEndings", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key+"\r\n"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("CorruptedChecksum", func(t *testing.T) {
+		corrupted := key[:len(key)-1] + "x"
+		if err := argon2id.VerifyPassword("password", corrupted); err != argon2id.ErrChecksumMismatch {
+			t.Fatal("Expected ErrChecksumMismatch.")
+		}
+	})
+}
+
+func TestNonce(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Nonce: true}
+
+	a, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Distinct", func(t *testing.T) {
+		if a == b {
+			t.Fatal("Expected distinct keys.")
+		}
+	})
+
+	t.Run("HashUnaffected", func(t *testing.T) {
+		verify := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA$n="
+		if !strings.HasPrefix(a, verify) || !strings.HasPrefix(b, verify) {
+			t.Fatal("Expected pre-defined hash followed by a nonce.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		for _, key := range []string{a, b} {
+			if err := argon2id.VerifyPassword("password", key); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := argon2id.VerifyPassword("password1", key); err != argon2id.ErrHashNotEqualPassword {
+				t.Fatal("Expected ErrHashNotEqualPassword.")
+			}
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		o := *options
+		o.Checksum = true
+
+		key, err := argon2id.HashPassword("password", "salt", &o)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", strings.Replace(key, "$n=", "$n=A", 1)); err != argon2id.ErrChecksumMismatch {
+			t.Fatal("Expected ErrChecksumMismatch.")
+		}
+	})
+
+	t.Run("UnknownTrailingSegment", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", a+"$x=1"); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+}
+
+// compareDurations runs both functions n times per round, alternating
+// between them for several rounds, and returns the fastest round of each.
+// Interleaving the rounds keeps GC pauses and noisy neighbours from skewing
+// only one of the measurements.
+func compareDurations(n int, a func(), b func()) (time.Duration, time.Duration) {
+	var fastest [2]time.Duration
+
+	runtime.GC()
+
+	for round := 0; round < 10; round++ {
+		for i, f := range []func(){a, b} {
+			start := time.Now()
+			for j := 0; j < n; j++ {
+				f()
+			}
+
+			if d := time.Since(start); round == 0 || d < fastest[i] {
+				fastest[i] = d
+			}
+		}
+	}
+
+	return fastest[0], fastest[1]
+}
+
+func TestCompareHashConstantTime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping timing measurement in short mode.")
+	}
+
+	a := bytes.Repeat([]byte{0xaa}, 4096)
+	equal := bytes.Repeat([]byte{0xaa}, 4096)
+	early := append([]byte{0x00}, equal[1:]...)
+
+	matching, mismatching := compareDurations(10000,
+		func() { argon2id.CompareHash(a, equal) },
+		func() { argon2id.CompareHash(a, early) },
+	)
+
+	slow, fast := matching, mismatching
+	if fast > slow {
+		slow, fast = fast, slow
+	}
+
+	if slow > 3*fast {
+		t.Fatalf("Expected constant time comparison, got %v for a match and %v for an early mismatch.", matching, mismatching)
+	}
+}
+
+func TestCompareHashLengths(t *testing.T) {
+	a := []byte{1, 2, 3, 4}
+
+	t.Run("Equal", func(t *testing.T) {
+		if !argon2id.CompareHash(a, []byte{1, 2, 3, 4}) {
+			t.Fatal("Expected equal hashes.")
+		}
+	})
+
+	t.Run("Shorter", func(t *testing.T) {
+		if argon2id.CompareHash(a, []byte{1, 2, 3}) || argon2id.CompareHash([]byte{1, 2, 3}, a) {
+			t.Fatal("Did not expect equal hashes.")
+		}
+	})
+
+	t.Run("ZeroPadded", func(t *testing.T) {
+		if argon2id.CompareHash([]byte{1, 2, 0}, []byte{1, 2}) || argon2id.CompareHash([]byte{1, 2}, []byte{1, 2, 0}) {
+			t.Fatal("Did not expect padding to make hashes equal.")
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if argon2id.CompareHash(nil, a) || !argon2id.CompareHash(nil, []byte{}) {
+			t.Fatal("Expected only empty hashes to be equal.")
+		}
+	})
+
+	t.Run("UniformTime", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping timing measurement in short mode.")
+		}
+
+		long := bytes.Repeat([]byte{0xaa}, 4096)
+		equal := bytes.Repeat([]byte{0xaa}, 4096)
+		short := long[:1]
+
+		sameLength, otherLength := compareDurations(10000,
+			func() { argon2id.CompareHash(long, equal) },
+			func() { argon2id.CompareHash(long, short) },
+		)
+
+		slow, fast := sameLength, otherLength
+		if fast > slow {
+			slow, fast = fast, slow
+		}
+
+		if slow > 3*fast {
+			t.Fatalf("Expected uniform time comparison, got %v for equal lengths and %v for differing lengths.", sameLength, otherLength)
+		}
+	})
+}
+
+func benchmarkVerifyPassword(b *testing.B, password string) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			argon2id.VerifyPassword(password, key)
+		}
+	})
+}
+
+func BenchmarkVerifyPassword(b *testing.B) {
+	benchmarkVerifyPassword(b, "password")
+}
+
+// benchmarkCompareHash compares a 32 byte hash to a copy whose last byte is
+// xored with the given value, so a zero value benchmarks a match and any
+// other value a mismatch found only at the very end.
+func benchmarkCompareHash(b *testing.B, last byte) {
+	a := bytes.Repeat([]byte{0xaa}, 32)
+	other := bytes.Repeat([]byte{0xaa}, 32)
+	other[len(other)-1] ^= last
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		argon2id.CompareHash(a, other)
+	}
+}
+
+func BenchmarkCompareHashMatch(b *testing.B) {
+	benchmarkCompareHash(b, 0)
+}
+
+func BenchmarkCompareHashNearMatch(b *testing.B) {
+	benchmarkCompareHash(b, 1)
+}
+
+// benchmarkBytesEqual works like benchmarkCompareHash but uses bytes.Equal as
+// the variable time baseline.
+func benchmarkBytesEqual(b *testing.B, first byte) {
+	a := bytes.Repeat([]byte{0xaa}, 32)
+	other := bytes.Repeat([]byte{0xaa}, 32)
+	other[0] ^= first
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bytes.Equal(a, other)
+	}
+}
+
+func BenchmarkBytesEqualMatch(b *testing.B) {
+	benchmarkBytesEqual(b, 0)
+}
+
+func BenchmarkBytesEqualEarlyMismatch(b *testing.B) {
+	benchmarkBytesEqual(b, 1)
+}
+
+func TestVerifyPasswordVersions(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+	crafted := strings.Replace(key, "v=19", "v=20", 1)
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordVersions("", key, []int{19}); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("Included", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordVersions("password", key, []int{16, 19}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPasswordVersions("password", crafted, []int{19, 20}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Excluded", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordVersions("password", key, []int{16}); !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+			t.Fatal("Expected ErrArgonVersionMismatch.")
+		}
+
+		if err := argon2id.VerifyPasswordVersions("password", crafted, nil); !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+			t.Fatal("Expected ErrArgonVersionMismatch.")
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordVersions("password1", crafted, []int{20}); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
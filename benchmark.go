@@ -0,0 +1,66 @@
+package argon2id
+
+import (
+	"runtime"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// variants maps the argon2 variants supported by golang.org/x/crypto/argon2
+// to their key derivation functions.
+var variants = map[string]func(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte{
+	"argon2i":  argon2.Key,
+	"argon2id": argon2.IDKey,
+}
+
+// CompareVariants runs a single derivation with the given options for every
+// argon2 variant supported by golang.org/x/crypto/argon2 and returns how long
+// each one took, keyed by variant name. It helps judging the cost difference
+// between the variants on the current host. It returns the error of
+// Options.Validate if the options can not be used with argon2.
+func CompareVariants(options *Options) (map[string]time.Duration, error) {
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	durations := make(map[string]time.Duration, len(variants))
+
+	for name, derive := range variants {
+		start := now()
+		derive(
+			[]byte("password"), []byte("somesalt"),
+			options.Time, options.Memory, options.Threads, options.KeyLen,
+		)
+		durations[name] = now().Sub(start)
+	}
+
+	return durations, nil
+}
+
+// MeasureHashMemory runs a single hash using the given options and returns the
+// number of bytes allocated while it ran. It compares the cumulative
+// TotalAlloc of runtime.MemStats before and after the hash instead of sampling
+// the heap, so the result does not depend on when the garbage collector runs.
+// argon2 allocates its whole memory matrix at once, so the result should be
+// slightly above Memory KiB. Allocations of other goroutines running at the
+// same time are included in the result. It returns the error of
+// Options.Validate if the options can not be used with argon2.
+func MeasureHashMemory(options *Options) (uint64, error) {
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+
+	var before, after runtime.MemStats
+
+	runtime.ReadMemStats(&before)
+	argon2.IDKey(
+		[]byte("password"), []byte("somesalt"),
+		options.Time, options.Memory, options.Threads, options.KeyLen,
+	)
+	runtime.ReadMemStats(&after)
+
+	return after.TotalAlloc - before.TotalAlloc, nil
+}
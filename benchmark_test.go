@@ -0,0 +1,75 @@
+package argon2id_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestCompareVariants(t *testing.T) {
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := argon2id.CompareVariants(&argon2id.Options{Time: 1, Memory: 64}); !errors.Is(err, argon2id.ErrInvalidOptions) {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+
+		if _, err := argon2id.CompareVariants(&argon2id.Options{Time: 1, Memory: 64, Threads: 1}); err != argon2id.ErrInvalidKeyLen {
+			t.Fatal("Expected ErrInvalidKeyLen.")
+		}
+	})
+
+	t.Run("PositiveDurations", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping host measurement in short mode.")
+		}
+
+		durations, err := argon2id.CompareVariants(argon2id.DefaultOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, variant := range []string{"argon2i", "argon2id"} {
+			if d, ok := durations[variant]; !ok {
+				t.Fatalf("Expected duration for %s.", variant)
+			} else if d <= 0 {
+				t.Fatalf("Expected positive duration for %s.", variant)
+			}
+		}
+	})
+}
+
+func TestMeasureHashMemory(t *testing.T) {
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := argon2id.MeasureHashMemory(&argon2id.Options{Memory: 64, Threads: 1}); !errors.Is(err, argon2id.ErrInvalidOptions) {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+
+		if _, err := argon2id.MeasureHashMemory(&argon2id.Options{Time: 1, Memory: 64, Threads: 1}); err != argon2id.ErrInvalidKeyLen {
+			t.Fatal("Expected ErrInvalidKeyLen.")
+		}
+	})
+
+	t.Run("ScalesWithMemory", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping memory measurement in short mode.")
+		}
+
+		small, err := argon2id.MeasureHashMemory(&argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		large, err := argon2id.MeasureHashMemory(&argon2id.Options{Time: 1, Memory: 32 * 1024, Threads: 1, KeyLen: 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if small < 8*1024*1024 {
+			t.Fatalf("Expected at least 8 MiB, got %d bytes.", small)
+		}
+
+		if large < 32*1024*1024 || large < 3*small {
+			t.Fatalf("Expected measurement to scale with memory, got %d and %d bytes.", small, large)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package argon2id
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// binaryVariants maps the variant byte of binary keys to the variant names.
+var binaryVariants = []string{"argon2d", "argon2i", "argon2id"}
+
+// binaryVariantID is the variant byte of argon2id in binary keys.
+const binaryVariantID = 2
+
+// EncodeBinary returns a compact binary argon2 key for the given options, salt
+// and hash. It is meant for devices with tight storage and is about half the
+// size of the PHC string. The layout is:
+//
+//	1 byte   variant (0 argon2d, 1 argon2i, 2 argon2id)
+//	1 byte   version
+//	4 bytes  memory, big endian
+//	4 bytes  time, big endian
+//	1 byte   threads
+//	uvarint  salt length, followed by the salt
+//	uvarint  hash length, followed by the hash
+//	uvarint  label length, followed by Options.Label, only if it is set
+func EncodeBinary(options *Options, salt []byte, hash []byte) []byte {
+	options = optionsOrDefault(options)
+
+	fields := [][]byte{salt, hash}
+	if options.Label != "" {
+		fields = append(fields, []byte(options.Label))
+	}
+
+	blob := make([]byte, 0, 11+3*binary.MaxVarintLen64+len(salt)+len(hash)+len(options.Label))
+	blob = append(blob, binaryVariantID, argon2.Version)
+
+	var b [binary.MaxVarintLen64]byte
+
+	binary.BigEndian.PutUint32(b[:], options.Memory)
+	blob = append(blob, b[:4]...)
+	binary.BigEndian.PutUint32(b[:], options.Time)
+	blob = append(blob, b[:4]...)
+	blob = append(blob, options.Threads)
+
+	for _, field := range fields {
+		n := binary.PutUvarint(b[:], uint64(len(field)))
+		blob = append(blob, b[:n]...)
+		blob = append(blob, field...)
+	}
+
+	return blob
+}
+
+// DecodeBinary parses a binary argon2 key created by EncodeBinary. The
+// Encoding of the returned KeyInfo is nil, the label is returned as
+// Options.Label. It returns ErrInvalidBinaryKey if the key is truncated, has
+// trailing bytes or an unknown variant.
+func DecodeBinary(blob []byte) (*KeyInfo, error) {
+	if len(blob) < 11 || int(blob[0]) >= len(binaryVariants) {
+		return nil, ErrInvalidBinaryKey
+	}
+
+	info := &KeyInfo{
+		Key: Key{
+			Variant: binaryVariants[blob[0]],
+			Version: int(blob[1]),
+			Options: Options{
+				Memory:  binary.BigEndian.Uint32(blob[2:6]),
+				Time:    binary.BigEndian.Uint32(blob[6:10]),
+				Threads: blob[10],
+			},
+		},
+	}
+
+	salt, rest, err := readBinaryField(blob[11:])
+	if err != nil {
+		return nil, err
+	}
+
+	hash, rest, err := readBinaryField(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		label, rest, err := readBinaryField(rest)
+		if err != nil || len(label) == 0 || len(rest) != 0 {
+			return nil, ErrInvalidBinaryKey
+		}
+
+		info.Options.Label = string(label)
+	}
+
+	info.Salt, info.Hash = salt, hash
+	info.Options.KeyLen = uint32(len(info.Hash))
+
+	return info, nil
+}
+
+// readBinaryField reads a uvarint length prefixed field from the start of the
+// given bytes. It returns a copy of the field and the bytes following it.
+func readBinaryField(rest []byte) ([]byte, []byte, error) {
+	length, n := binary.Uvarint(rest)
+	if n <= 0 || length > uint64(len(rest)-n) {
+		return nil, nil, ErrInvalidBinaryKey
+	}
+
+	return append([]byte(nil), rest[n:n+int(length)]...), rest[n+int(length):], nil
+}
+
+// VerifyBinary takes a password and a binary argon2 key created by
+// EncodeBinary and compares both. It will return an error if they are not
+// equal.
+func VerifyBinary(password string, blob []byte) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	info, err := DecodeBinary(blob)
+	if err != nil {
+		return err
+	}
+
+	return info.Verify(password)
+}
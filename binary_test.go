@@ -0,0 +1,108 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestBinary(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	info, err := argon2id.Inspect(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := argon2id.EncodeBinary(&info.Options, info.Salt, info.Hash)
+
+	t.Run("Size", func(t *testing.T) {
+		if len(blob) >= len(key) {
+			t.Fatal("Expected binary key to be smaller than the PHC string.")
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		decoded, err := argon2id.DecodeBinary(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Variant != "argon2id" || decoded.Version != 19 {
+			t.Fatal("Expected argon2id version 19.")
+		}
+
+		if !reflect.DeepEqual(decoded.Options, info.Options) {
+			t.Fatal("Expected pinned options.")
+		}
+
+		if !bytes.Equal(decoded.Salt, info.Salt) || !bytes.Equal(decoded.Hash, info.Hash) {
+			t.Fatal("Expected pinned salt and hash.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := argon2id.VerifyBinary("password", blob); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Label", func(t *testing.T) {
+		labeled, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Label: "login"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		options, salt, hash, err := argon2id.ParseKey(labeled)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		labeledBlob := argon2id.EncodeBinary(options, salt, hash)
+
+		decoded, err := argon2id.DecodeBinary(labeledBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Options.Label != "login" {
+			t.Fatal("Expected label to be kept.")
+		}
+
+		if err := argon2id.VerifyBinary("password", labeledBlob); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyBinary("password1", blob); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("UnsupportedVariant", func(t *testing.T) {
+		other := append([]byte(nil), blob...)
+		other[0] = 1
+
+		if err := argon2id.VerifyBinary("password", other); err != argon2id.ErrUnsupportedVariant {
+			t.Fatal("Expected ErrUnsupportedVariant.")
+		}
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, b := range [][]byte{
+			nil,
+			blob[:10],
+			blob[:len(blob)-1],
+			append(append([]byte(nil), blob...), 0),
+			append(append([]byte(nil), blob...), 1),
+			append([]byte{3}, blob[1:]...),
+		} {
+			if _, err := argon2id.DecodeBinary(b); err != argon2id.ErrInvalidBinaryKey {
+				t.Fatal("Expected ErrInvalidBinaryKey.")
+			}
+		}
+	})
+}
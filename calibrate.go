@@ -0,0 +1,91 @@
+package argon2id
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// calibrateRounds is the maximum number of measurements Calibrate takes
+// before it settles on the last options.
+const calibrateRounds = 10
+
+// benchmark runs a single hash using the given options against a fixed
+// password and salt and returns how long it took.
+func benchmark(options *Options) time.Duration {
+	start := now()
+	argon2.IDKey(
+		[]byte("password"), []byte("somesalt"),
+		options.Time, options.Memory, options.Threads, options.KeyLen,
+	)
+
+	return now().Sub(start)
+}
+
+// Benchmark returns how long a single hash using the given options takes on
+// the current host, measured against a fixed password and salt. It can be used
+// to monitor the cost of the options as parameters or hardware change. Nil
+// options are treated as DefaultOptions. It returns the error of
+// Options.Validate if the options can not be used with argon2.
+func Benchmark(options *Options) (time.Duration, error) {
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+
+	return benchmark(options), nil
+}
+
+// Calibrate returns options for which a single hash takes approximately the
+// target duration on the current host, using the given number of threads. It
+// repeatedly hashes a throwaway password, starting at 8 MiB, and scales Memory
+// by the ratio between the target and the measured duration without exceeding
+// maxMemory, given in KiB. Once maxMemory is reached Time is raised instead.
+// It is meant to be run once at startup. ErrInvalidOptions is returned if the
+// target is not positive, threads is zero or maxMemory is smaller than 8 KiB
+// per thread.
+func Calibrate(target time.Duration, maxMemory uint32, threads uint8) (*Options, error) {
+	minMemory := 8 * uint32(threads)
+	if target <= 0 || threads < 1 || maxMemory < minMemory {
+		return nil, ErrInvalidOptions
+	}
+
+	options := &Options{
+		Time:    1,
+		Memory:  8 * 1024,
+		Threads: threads,
+		KeyLen:  32,
+	}
+
+	if options.Memory > maxMemory {
+		options.Memory = maxMemory
+	}
+
+	for i := 0; i < calibrateRounds; i++ {
+		d := benchmark(options)
+		if d <= 0 {
+			d = 1
+		}
+
+		ratio := float64(target) / float64(d)
+		if ratio >= 0.8 && ratio <= 1.25 {
+			break
+		}
+
+		if ratio < 1 || options.Memory < maxMemory {
+			memory := math.Min(float64(options.Memory)*ratio, float64(maxMemory))
+			options.Memory = uint32(math.Max(memory, float64(minMemory)))
+
+			if ratio < 1 && options.Memory == minMemory && options.Time > 1 {
+				options.Time--
+			}
+
+			continue
+		}
+
+		options.Time = uint32(math.Ceil(float64(options.Time) * ratio))
+	}
+
+	return options, nil
+}
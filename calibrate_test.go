@@ -0,0 +1,84 @@
+package argon2id_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestBenchmark(t *testing.T) {
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := argon2id.Benchmark(&argon2id.Options{Time: 0, Memory: 8, Threads: 1, KeyLen: 32}); err != argon2id.ErrInvalidTime {
+			t.Fatal("Expected ErrInvalidTime.")
+		}
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		cheap, err := argon2id.Benchmark(&argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expensive, err := argon2id.Benchmark(&argon2id.Options{Time: 4, Memory: 16 * 1024, Threads: 1, KeyLen: 32})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cheap < 0 || expensive <= cheap {
+			t.Fatal("Expected more expensive options to take longer.")
+		}
+	})
+}
+
+func TestCalibrate(t *testing.T) {
+	t.Run("InvalidArguments", func(t *testing.T) {
+		for _, c := range []struct {
+			target    time.Duration
+			maxMemory uint32
+			threads   uint8
+		}{
+			{0, 64 * 1024, 1},
+			{time.Millisecond, 64 * 1024, 0},
+			{time.Millisecond, 31, 4},
+		} {
+			if _, err := argon2id.Calibrate(c.target, c.maxMemory, c.threads); err != argon2id.ErrInvalidOptions {
+				t.Fatalf("Expected ErrInvalidOptions for %+v.", c)
+			}
+		}
+	})
+
+	if testing.Short() {
+		t.Skip("Skipping calibration in short mode.")
+	}
+
+	t.Run("Memory", func(t *testing.T) {
+		options, err := argon2id.Calibrate(20*time.Millisecond, 64*1024, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := options.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		if options.Memory > 64*1024 || options.Threads != 1 {
+			t.Fatalf("Expected at most 64 MiB and 1 thread, got %+v.", options)
+		}
+	})
+
+	t.Run("TimeFallback", func(t *testing.T) {
+		options, err := argon2id.Calibrate(50*time.Millisecond, 64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if options.Memory > 64 {
+			t.Fatalf("Expected at most 64 KiB, got %+v.", options)
+		}
+
+		if options.Time < 2 {
+			t.Fatalf("Expected time to be raised, got %+v.", options)
+		}
+	})
+}
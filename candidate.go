@@ -0,0 +1,71 @@
+package argon2id
+
+import (
+	"sync"
+)
+
+// bufferPool holds byte slices that are used to hand passwords to argon2
+// without allocating a new slice for every call. Slices are zeroed before they
+// are returned to the pool.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// maxPooledBuffer is the capacity above which buffers are not returned to
+// bufferPool, so a single huge password does not stay in the pool.
+const maxPooledBuffer = 1024
+
+// copyToBuffer copies the password into a buffer taken from bufferPool. The
+// buffer must be handed back using releaseBuffer.
+func copyToBuffer(password string) (*[]byte, []byte) {
+	buf := bufferPool.Get().(*[]byte)
+	return buf, append((*buf)[:0], password...)
+}
+
+// releaseBuffer wipes the copy of a password made by copyToBuffer and returns
+// its buffer to bufferPool.
+func releaseBuffer(buf *[]byte, b []byte) {
+	wipe(b)
+
+	if cap(b) <= maxPooledBuffer {
+		*buf = b[:0]
+		bufferPool.Put(buf)
+	}
+}
+
+// CandidateTester tests many candidate passwords against a single argon2 key.
+// The key is parsed once and the decoded salt and hash are reused for every
+// candidate. Note that argon2 itself still allocates its memory for each
+// derivation.
+type CandidateTester struct {
+	options *Options
+	salt    []byte
+	hash    []byte
+}
+
+// NewCandidateTester parses the given argon2 key and returns a
+// CandidateTester for it.
+func NewCandidateTester(key string) (*CandidateTester, error) {
+	options, salt, hash, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CandidateTester{options: options, salt: salt, hash: hash}, nil
+}
+
+// Test reports whether the given password matches the key of the
+// CandidateTester. It is safe for concurrent use.
+func (c *CandidateTester) Test(password string) bool {
+	if password == "" {
+		return false
+	}
+
+	buf, b := copyToBuffer(password)
+	defer releaseBuffer(buf, b)
+
+	return verifyKeyBytes(b, c.options, c.salt, c.hash) == nil
+}
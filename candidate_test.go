@@ -0,0 +1,56 @@
+package argon2id_test
+
+import (
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestCandidateTester(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.NewCandidateTester("$argon2id$v=19"); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+
+	c, err := argon2id.NewCandidateTester(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Candidates", func(t *testing.T) {
+		for _, candidate := range []string{"", "123456", "passwor", "password1", "Password"} {
+			if c.Test(candidate) {
+				t.Fatalf("Did not expect %q to match.", candidate)
+			}
+		}
+
+		if !c.Test("password") {
+			t.Fatal("Expected password to match.")
+		}
+	})
+}
+
+func BenchmarkCandidateTester(b *testing.B) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	c, err := argon2id.NewCandidateTester(key)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Test("candidate")
+	}
+}
@@ -0,0 +1,56 @@
+package argon2id
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// CohortHasher selects between current and upgraded options per user to roll
+// out stronger options to a percentage of users at a time. The selection is
+// derived from a hash of the user id, so a user keeps getting the same options
+// while the percentage stays the same, and users selected at a lower
+// percentage stay selected when it is raised.
+type CohortHasher struct {
+	current    *Options
+	upgraded   *Options
+	percentage int
+}
+
+// NewCohortHasher returns a CohortHasher using the upgraded options for the
+// given percentage of users and the current options for everybody else.
+func NewCohortHasher(current *Options, upgraded *Options, percentage int) (*CohortHasher, error) {
+	if current == nil || upgraded == nil {
+		return nil, ErrInvalidOptions
+	}
+
+	if percentage < 0 || percentage > 100 {
+		return nil, ErrInvalidPercentage
+	}
+
+	return &CohortHasher{
+		current:    current,
+		upgraded:   upgraded,
+		percentage: percentage,
+	}, nil
+}
+
+// OptionsForUser returns the options to use for the user with the given id.
+func (h *CohortHasher) OptionsForUser(id string) *Options {
+	if cohort(id) < h.percentage {
+		return h.upgraded
+	}
+
+	return h.current
+}
+
+// Hash takes a user id, a password and a salt and returns an argon2 key using
+// the options selected for the user.
+func (h *CohortHasher) Hash(id string, password string, salt string) (string, error) {
+	return HashPassword(password, salt, h.OptionsForUser(id))
+}
+
+// cohort returns the cohort of the user with the given id, between 0 and 99.
+func cohort(id string) int {
+	sum := sha256.Sum256([]byte(id))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
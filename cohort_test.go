@@ -0,0 +1,81 @@
+package argon2id_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestCohortHasher(t *testing.T) {
+	current := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+	upgraded := &argon2id.Options{Time: 2, Memory: 16, Threads: 1, KeyLen: 32}
+
+	t.Run("InvalidPercentage", func(t *testing.T) {
+		for _, p := range []int{-1, 101} {
+			if _, err := argon2id.NewCohortHasher(current, upgraded, p); err != argon2id.ErrInvalidPercentage {
+				t.Fatal("Expected ErrInvalidPercentage.")
+			}
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		a, _ := argon2id.NewCohortHasher(current, upgraded, 50)
+		b, _ := argon2id.NewCohortHasher(current, upgraded, 50)
+
+		for i := 0; i < 100; i++ {
+			id := "user-" + strconv.Itoa(i)
+			if a.OptionsForUser(id) != b.OptionsForUser(id) {
+				t.Fatalf("Expected same options for %s.", id)
+			}
+		}
+	})
+
+	t.Run("Distribution", func(t *testing.T) {
+		for _, p := range []int{0, 10, 50, 100} {
+			h, err := argon2id.NewCohortHasher(current, upgraded, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := 10000
+			selected := 0
+
+			for i := 0; i < n; i++ {
+				if h.OptionsForUser("user-"+strconv.Itoa(i)) == upgraded {
+					selected++
+				}
+			}
+
+			if diff := selected*100/n - p; diff < -2 || diff > 2 {
+				t.Fatalf("Expected about %d%% upgraded, got %d of %d.", p, selected, n)
+			}
+		}
+	})
+
+	t.Run("Monotonic", func(t *testing.T) {
+		low, _ := argon2id.NewCohortHasher(current, upgraded, 10)
+		high, _ := argon2id.NewCohortHasher(current, upgraded, 60)
+
+		for i := 0; i < 1000; i++ {
+			id := "user-" + strconv.Itoa(i)
+			if low.OptionsForUser(id) == upgraded && high.OptionsForUser(id) != upgraded {
+				t.Fatalf("Expected %s to stay upgraded.", id)
+			}
+		}
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		h, _ := argon2id.NewCohortHasher(current, upgraded, 100)
+
+		key, err := h.Hash("user", "password", "salt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(key, "$argon2id$v=19$m=16,t=2,p=1$") {
+			t.Fatal("Expected upgraded options.")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package argon2id
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+)
+
+// contextPassword mixes the given context into the password. The resulting
+// message is uint32_be(len(context)) || context || password. The length prefix
+// makes sure that different splits of context and password never produce the
+// same message.
+func contextPassword(context string, password string) string {
+	return string(contextPasswordBytes(context, []byte(password)))
+}
+
+// contextPasswordBytes works like contextPassword but takes the password as a
+// byte slice and returns a new one, so the caller can wipe it after use.
+func contextPasswordBytes(context string, password []byte) []byte {
+	b := make([]byte, 4, 4+len(context)+len(password))
+	binary.BigEndian.PutUint32(b, uint32(len(context)))
+	b = append(b, context...)
+	b = append(b, password...)
+
+	return b
+}
+
+// labelSegments returns the trailing segment holding the label of the options,
+// or nil if they have no label.
+func (o *Options) labelSegments() []string {
+	if o.Label == "" {
+		return nil
+	}
+
+	return []string{labelPrefix + base64.RawURLEncoding.EncodeToString([]byte(o.Label))}
+}
+
+// parseLabel returns the label stored in the given trailing segments of a key.
+// It returns an empty string if there is none and an error matching
+// ErrInvalidBase64 if the label can not be decoded.
+func parseLabel(trailing []string) (string, error) {
+	for _, segment := range trailing {
+		if !strings.HasPrefix(segment, labelPrefix) {
+			continue
+		}
+
+		label, err := DecodeBase64String(segment[len(labelPrefix):])
+		if err != nil {
+			return "", withCause(ErrInvalidBase64, err)
+		}
+
+		return string(label), nil
+	}
+
+	return "", nil
+}
+
+// HashPasswordWithContext works like HashPassword but mixes a context label
+// (e.g. "login" or "recovery") into the derivation, so the same password and
+// salt produce different keys for different contexts. The context is not part
+// of the returned key and must be passed to VerifyPasswordWithContext again.
+func HashPasswordWithContext(password string, salt string, context string, options *Options) (string, error) {
+	if password == "" {
+		return "", ErrPasswordRequired
+	}
+
+	if context == "" {
+		return "", ErrContextRequired
+	}
+
+	return HashPassword(contextPassword(context, password), salt, options)
+}
+
+// VerifyPasswordWithContext takes a password, an argon2 key and the context
+// that was used by HashPasswordWithContext and compares them. It will return
+// an error if they are not equal.
+func VerifyPasswordWithContext(password string, key string, context string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if context == "" {
+		return ErrContextRequired
+	}
+
+	return VerifyPassword(contextPassword(context, password), key)
+}
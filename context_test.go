@@ -0,0 +1,200 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestHashPasswordWithContext(t *testing.T) {
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordWithContext("", "salt", "login", argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("EmptyContext", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordWithContext("password", "salt", "", argon2id.DefaultOptions); err != argon2id.ErrContextRequired {
+			t.Fatal("Expected ErrContextRequired.")
+		}
+	})
+
+	t.Run("DifferentContexts", func(t *testing.T) {
+		login, err := argon2id.HashPasswordWithContext("password", "salt", "login", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recovery, err := argon2id.HashPasswordWithContext("password", "salt", "recovery", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plain, err := argon2id.HashPassword("password", "salt", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if login == recovery || login == plain || recovery == plain {
+			t.Fatal("Expected different keys per context.")
+		}
+	})
+
+	t.Run("AmbiguousSplit", func(t *testing.T) {
+		a, err := argon2id.HashPasswordWithContext("bc", "salt", "a", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := argon2id.HashPasswordWithContext("c", "salt", "ab", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a == b {
+			t.Fatal("Did not expect equal keys.")
+		}
+	})
+}
+
+func TestVerifyPasswordWithContext(t *testing.T) {
+	key, err := argon2id.HashPasswordWithContext("password", "salt", "login", shortSaltOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EmptyContext", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithContext("password", key, ""); err != argon2id.ErrContextRequired {
+			t.Fatal("Expected ErrContextRequired.")
+		}
+	})
+
+	t.Run("SameContext", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithContext("password", key, "login"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OtherContext", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithContext("password", key, "recovery"); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WithoutContext", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordWithContext("password1", key, "login"); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestOptionsLabel(t *testing.T) {
+	labeled := func(label string) *argon2id.Options {
+		options := *shortSaltOptions
+		options.Label = label
+
+		return &options
+	}
+
+	login, err := argon2id.HashPassword("password", "salt", labeled("login"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("UnrelatedHashes", func(t *testing.T) {
+		vault, err := argon2id.HashPassword("password", "salt", labeled("vault"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := argon2id.DecodeKey(login)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := argon2id.DecodeKey(vault)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(a.Hash, b.Hash) {
+			t.Fatal("Expected different hashes per label.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", login); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password1", login); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("ContextConstruction", func(t *testing.T) {
+		key, err := argon2id.HashPasswordWithContext("password", "salt", "login", shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(login, key+"$label=") {
+			t.Fatal("Expected hash of HashPasswordWithContext followed by label segment.")
+		}
+	})
+
+	t.Run("TamperedLabel", func(t *testing.T) {
+		key := strings.Replace(login, "$label=bG9naW4", "$label=dmF1bHQ", 1)
+
+		if err := argon2id.VerifyPassword("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("InvalidLabel", func(t *testing.T) {
+		key := strings.Replace(login, "$label=bG9naW4", "$label=!", 1)
+
+		if err := argon2id.VerifyPassword("password", key); !errors.Is(err, argon2id.ErrInvalidBase64) {
+			t.Fatal("Expected ErrInvalidBase64.")
+		}
+	})
+
+	t.Run("DecodeKey", func(t *testing.T) {
+		k, err := argon2id.DecodeKey(login)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k.Options.Label != "login" {
+			t.Fatal("Expected label login.")
+		}
+
+		if k.String() != login {
+			t.Fatal("Expected original key.")
+		}
+
+		if err := k.Verify("password"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("CandidateTester", func(t *testing.T) {
+		c, err := argon2id.NewCandidateTester(login)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !c.Test("password") {
+			t.Fatal("Expected password to match.")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package argon2id
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// corpusPassword is the password of the valid keys in the fuzz corpus.
+const corpusPassword = "password"
+
+// corpusOptions are cheap options used for the valid keys in the fuzz corpus,
+// so the fuzzer spends its time in the parser rather than in argon2.
+var corpusOptions = Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 16}
+
+// GenerateFuzzCorpus writes seed files for FuzzVerifyPassword into dir,
+// creating it if needed. Files named "valid-N" contain keys of the password
+// "password", "boundary-N" contain structurally valid keys with edge case
+// parameters and "malformed-N" contain broken keys. The files use the corpus
+// format of go test, so dir can be testdata/fuzz/FuzzVerifyPassword.
+func GenerateFuzzCorpus(dir string) error {
+	checksummed := corpusOptions
+	checksummed.Checksum = true
+
+	valid := []string{}
+	for _, o := range []*Options{&corpusOptions, &checksummed} {
+		for _, salt := range []string{"somesalt", "s", "0123456789abcdef0123456789abcdef"} {
+			key, err := HashPassword(corpusPassword, salt, o)
+			if err != nil {
+				return err
+			}
+
+			valid = append(valid, key)
+		}
+	}
+
+	boundary := []string{
+		"$argon2id$v=19$m=8,t=1,p=1$$",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AA",
+		"$argon2id$v=0x13$p=1,t=1,m=8$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=2040,t=1,p=255$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA$crc=00000000",
+	}
+
+	malformed := []string{
+		"",
+		"$",
+		"$$$$$",
+		"$$$$$$",
+		"argon2id",
+		"$argon2id$v=19",
+		"$argon2i$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+		"$argon2id$v=x$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=18$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=0,p=0$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1,p=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA=$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$*",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA$crc=zz",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA$extra$extra",
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for prefix, keys := range map[string][]string{
+		"valid":     valid,
+		"boundary":  boundary,
+		"malformed": malformed,
+	} {
+		for i, key := range keys {
+			name := filepath.Join(dir, fmt.Sprintf("%s-%d", prefix, i))
+			data := "go test fuzz v1\nstring(" + strconv.Quote(key) + ")\n"
+
+			if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package argon2id_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestGenerateFuzzCorpus(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "testdata", "fuzz", "FuzzVerifyPassword")
+
+	if err := argon2id.GenerateFuzzCorpus(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) != 2 || lines[0] != "go test fuzz v1" {
+			t.Fatalf("Expected go test corpus format in %s.", entry.Name())
+		}
+
+		if !strings.HasPrefix(lines[1], "string(") || !strings.HasSuffix(lines[1], ")") {
+			t.Fatalf("Expected string value in %s.", entry.Name())
+		}
+
+		key, err := strconv.Unquote(lines[1][len("string(") : len(lines[1])-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prefix := entry.Name()[:strings.IndexByte(entry.Name(), '-')]
+		counts[prefix]++
+
+		switch prefix {
+		case "valid":
+			if err := argon2id.VerifyPassword("password", key); err != nil {
+				t.Fatalf("Expected %s to verify: %v", entry.Name(), err)
+			}
+		case "malformed":
+			if err := argon2id.VerifyPassword("password", key); err == nil {
+				t.Fatalf("Expected %s to fail.", entry.Name())
+			}
+		}
+	}
+
+	for _, prefix := range []string{"valid", "boundary", "malformed"} {
+		if counts[prefix] == 0 {
+			t.Fatalf("Expected %s seed files.", prefix)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package argon2id
+
+import (
+	"crypto/sha256"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// maxDeriveLength is the maximum output length of HKDF-SHA256.
+const maxDeriveLength = 255 * sha256.Size
+
+// DeriveKey works like HashPassword but also returns the raw argon2 output.
+// The raw output is the hash contained in the encoded key, so anybody holding
+// the encoded key can recover it. It must not be used as an encryption key if
+// the encoded key is stored next to the encrypted data; use VerifyThenDerive
+// to derive a key that can not be computed from the stored key.
+func DeriveKey(password string, salt string, options *Options) (raw []byte, encoded string, err error) {
+	if err := checkPasswordLength(len(password)); err != nil {
+		return nil, "", err
+	}
+
+	b := []byte(password)
+	defer wipe(b)
+
+	return deriveKeyBytes(b, []byte(salt), options)
+}
+
+// VerifyRaw takes a password, a salt and a raw hash, e.g. as returned by
+// DeriveKey, and compares the password to the hash using the given options.
+// It is meant for schemas storing salt, hash and parameters in separate
+// columns. Nil options are treated as DefaultOptions and Options.Secret is
+// applied like HashPassword does. It returns ErrKeyLenMismatch if the hash is
+// not KeyLen bytes long and ErrHashNotEqualPassword if they are not equal.
+func VerifyRaw(password string, salt []byte, hash []byte, options *Options) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if len(salt) == 0 {
+		return ErrSaltRequired
+	}
+
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	if uint32(len(hash)) != options.KeyLen {
+		return ErrKeyLenMismatch
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	buf, b := copyToBuffer(password)
+	defer releaseBuffer(buf, b)
+
+	if len(options.Secret) > 0 {
+		b = pepperPasswordBytes(options.Secret, b)
+		defer wipe(b)
+	}
+
+	return verifyKeyBytes(b, options, salt, hash)
+}
+
+// VerifyThenDerive verifies the password against the argon2 key and, on
+// success, derives a data key of deriveLen bytes for the given info label.
+//
+// The argon2 output of the verification is stored in the key itself, so
+// expanding it would let anyone holding the key compute the data key. Instead
+// a second argon2 output is derived from the info label and the password (see
+// HashPasswordWithContext) using the salt and parameters of the key, and the
+// data key is HKDF-Expand(SHA-256, output, info). This doubles the cost of the
+// call. The same password, key and info always yield the same data key.
+func VerifyThenDerive(password string, key string, info string, deriveLen uint32) ([]byte, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	if deriveLen == 0 || deriveLen > maxDeriveLength {
+		return nil, ErrInvalidDeriveLength
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipe(salt, hash)
+
+	if err := verifyKey(password, p, salt, hash); err != nil {
+		return nil, err
+	}
+
+	secret := argon2.IDKey(
+		[]byte(contextPassword(info, password)), salt,
+		p.Time, p.Memory, p.Threads, sha256.Size,
+	)
+
+	defer wipe(secret)
+
+	dataKey := make([]byte, deriveLen)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, secret, []byte(info)), dataKey); err != nil {
+		return nil, err
+	}
+
+	return dataKey, nil
+}
+
+// numberedContextPrefix is prepended to the index of every key derived by
+// DeriveNumbered, keeping them apart from contexts chosen by callers of
+// HashPasswordWithContext.
+const numberedContextPrefix = "argon2id.DeriveNumbered:"
+
+// DeriveNumbered derives count independent keys of keyLen bytes from the
+// password and salt, e.g. one per device. Key i is the argon2 output for the
+// context "argon2id.DeriveNumbered:<i>" (see HashPasswordWithContext) using
+// the time, memory and threads of the options. Options.Secret and
+// Options.Label are applied to the password first, like HashPassword does. The
+// keys are reproducible from the same inputs, and each one costs a full
+// derivation. It returns the error of Options.Validate if the options can not
+// be used with argon2.
+func DeriveNumbered(password string, salt string, count int, keyLen uint32, options *Options) ([][]byte, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	if salt == "" {
+		return nil, ErrSaltRequired
+	}
+
+	if count < 1 {
+		return nil, ErrInvalidCount
+	}
+
+	if keyLen == 0 {
+		return nil, ErrInvalidDeriveLength
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return nil, err
+	}
+
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := options.checkSaltLength([]byte(salt)); err != nil {
+		return nil, err
+	}
+
+	b := []byte(password)
+	defer wipe(b)
+
+	if len(options.Secret) > 0 {
+		b = pepperPasswordBytes(options.Secret, b)
+		defer wipe(b)
+	}
+
+	if options.Label != "" {
+		b = contextPasswordBytes(options.Label, b)
+		defer wipe(b)
+	}
+
+	keys := make([][]byte, count)
+	for i := range keys {
+		numbered := contextPasswordBytes(numberedContextPrefix+strconv.Itoa(i), b)
+
+		keys[i] = argon2.IDKey(
+			numbered, []byte(salt),
+			options.Time, options.Memory, options.Threads, keyLen,
+		)
+
+		wipe(numbered)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,259 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+	"golang.org/x/crypto/hkdf"
+)
+
+func TestVerifyThenDerive(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyThenDerive("", key, "data", 32); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("InvalidDeriveLength", func(t *testing.T) {
+		for _, l := range []uint32{0, 255*32 + 1} {
+			if _, err := argon2id.VerifyThenDerive("password", key, "data", l); err != argon2id.ErrInvalidDeriveLength {
+				t.Fatalf("Expected ErrInvalidDeriveLength for %d.", l)
+			}
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyThenDerive("password1", key, "data", 32); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	dataKey, err := argon2id.VerifyThenDerive("password", key, "data", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Length", func(t *testing.T) {
+		if len(dataKey) != 32 {
+			t.Fatal("Expected data key of 32 bytes.")
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		if again, err := argon2id.VerifyThenDerive("password", key, "data", 32); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(dataKey, again) {
+			t.Fatal("Expected equal data keys.")
+		}
+	})
+
+	t.Run("OtherInfo", func(t *testing.T) {
+		if other, err := argon2id.VerifyThenDerive("password", key, "other", 32); err != nil {
+			t.Fatal(err)
+		} else if bytes.Equal(dataKey, other) {
+			t.Fatal("Did not expect equal data keys.")
+		}
+	})
+
+	t.Run("NotDerivableFromKey", func(t *testing.T) {
+		hash, err := argon2id.DecodeBase64String("OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		leaked := make([]byte, 32)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, hash, []byte("data")), leaked); err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(dataKey, leaked) {
+			t.Fatal("Did not expect data key to be derivable from the stored key.")
+		}
+	})
+}
+
+func TestDeriveNumbered(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 32}
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		if _, err := argon2id.DeriveNumbered("", "salt", 2, 32, options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+
+		if _, err := argon2id.DeriveNumbered("password", "", 2, 32, options); err != argon2id.ErrSaltRequired {
+			t.Fatal("Expected ErrSaltRequired.")
+		}
+
+		if _, err := argon2id.DeriveNumbered("password", "salt", 0, 32, options); err != argon2id.ErrInvalidCount {
+			t.Fatal("Expected ErrInvalidCount.")
+		}
+
+		if _, err := argon2id.DeriveNumbered("password", "salt", 2, 0, options); err != argon2id.ErrInvalidDeriveLength {
+			t.Fatal("Expected ErrInvalidDeriveLength.")
+		}
+
+		if _, err := argon2id.DeriveNumbered("password", "saltsalt", 1, 16, &argon2id.Options{}); !errors.Is(err, argon2id.ErrInvalidOptions) {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+	})
+
+	keys, err := argon2id.DeriveNumbered("password", "salt", 4, 24, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Count", func(t *testing.T) {
+		if len(keys) != 4 {
+			t.Fatal("Expected 4 keys.")
+		}
+
+		for _, k := range keys {
+			if len(k) != 24 {
+				t.Fatal("Expected keys of 24 bytes.")
+			}
+		}
+	})
+
+	t.Run("Distinct", func(t *testing.T) {
+		for i := range keys {
+			for j := i + 1; j < len(keys); j++ {
+				if bytes.Equal(keys[i], keys[j]) {
+					t.Fatalf("Did not expect keys %d and %d to be equal.", i, j)
+				}
+			}
+		}
+	})
+
+	t.Run("SecretAndLabel", func(t *testing.T) {
+		for _, change := range []func(o *argon2id.Options){
+			func(o *argon2id.Options) { o.Secret = []byte("pepper") },
+			func(o *argon2id.Options) { o.Label = "vault" },
+		} {
+			o := *options
+			change(&o)
+
+			other, err := argon2id.DeriveNumbered("password", "salt", 1, 24, &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Equal(keys[0], other[0]) {
+				t.Fatal("Expected different key.")
+			}
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		again, err := argon2id.DeriveNumbered("password", "salt", 2, 24, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range again {
+			if !bytes.Equal(keys[i], again[i]) {
+				t.Fatalf("Expected key %d to be reproducible.", i)
+			}
+		}
+	})
+}
+
+func TestDeriveKey(t *testing.T) {
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, err := argon2id.DeriveKey("", "salt", argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	raw, encoded, err := argon2id.DeriveKey("password", "salt", shortSaltOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Encoded", func(t *testing.T) {
+		if encoded != "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU" {
+			t.Fatal("Expected pre-defined hash.")
+		}
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		_, _, hash, err := argon2id.ParseKey(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(raw, hash) {
+			t.Fatal("Expected raw output to equal the encoded hash.")
+		}
+	})
+}
+
+func TestVerifyRaw(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	raw, _, err := argon2id.DeriveKey("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		if err := argon2id.VerifyRaw("password", []byte("salt"), raw, options); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyRaw("password1", []byte("salt"), raw, options); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongOptions", func(t *testing.T) {
+		other := *options
+		other.Time = 2
+
+		if err := argon2id.VerifyRaw("password", []byte("salt"), raw, &other); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("KeyLenMismatch", func(t *testing.T) {
+		if err := argon2id.VerifyRaw("password", []byte("salt"), raw[:16], options); err != argon2id.ErrKeyLenMismatch {
+			t.Fatal("Expected ErrKeyLenMismatch.")
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		peppered := *options
+		peppered.Secret = []byte("secret")
+
+		raw, _, err := argon2id.DeriveKey("password", "salt", &peppered)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyRaw("password", []byte("salt"), raw, &peppered); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyRaw("password", []byte("salt"), raw, options); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		if err := argon2id.VerifyRaw("", []byte("salt"), raw, options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+
+		if err := argon2id.VerifyRaw("password", nil, raw, options); err != argon2id.ErrSaltRequired {
+			t.Fatal("Expected ErrSaltRequired.")
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package argon2id
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// DetectEncoding inspects the salt and hash of the given argon2 key and
+// returns the base64 encoding they were written with. Keys containing "-" or
+// "_" are RawURLEncoding, keys containing "+" or "/" are RawStdEncoding. If
+// neither alphabet specific character is present both encodings decode the
+// key identically and RawURLEncoding, the encoding used by this package, is
+// returned. Padded segments return ErrInvalidEncoding.
+func DetectEncoding(key string) (*base64.Encoding, error) {
+	decodedKey, err := splitKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPadded(decodedKey[4]) || isPadded(decodedKey[5]) {
+		return nil, ErrInvalidEncoding
+	}
+
+	data := decodedKey[4] + decodedKey[5]
+	url := strings.ContainsAny(data, "-_")
+	std := strings.ContainsAny(data, "+/")
+
+	if url && std {
+		return nil, ErrUnknownEncoding
+	}
+
+	encoding := base64.RawURLEncoding
+	if std {
+		encoding = base64.RawStdEncoding
+	}
+
+	for _, segment := range decodedKey[4:] {
+		if _, err := encoding.DecodeString(segment); err != nil {
+			return nil, ErrUnknownEncoding
+		}
+	}
+
+	return encoding, nil
+}
+
+// EncodedKeyLength returns the exact length of the argon2 key HashPassword
+// produces for the given options and a salt of saltLen bytes. It can be used
+// to size database columns.
+func EncodedKeyLength(options *Options, saltLen uint32) int {
+	options = optionsOrDefault(options)
+
+	length := len("$argon2id$v=") + len(strconv.Itoa(options.version())) +
+		len("$m=") + len(strconv.FormatUint(uint64(options.Memory), 10)) +
+		len(",t=") + len(strconv.FormatUint(uint64(options.Time), 10)) +
+		len(",p=") + len(strconv.FormatUint(uint64(options.Threads), 10)) +
+		len("$") + base64.RawURLEncoding.EncodedLen(int(saltLen)) +
+		len("$") + base64.RawURLEncoding.EncodedLen(int(options.KeyLen))
+
+	if options.Nonce {
+		length += len("$") + len(noncePrefix) + base64.RawURLEncoding.EncodedLen(nonceLength)
+	}
+
+	for _, segment := range options.labelSegments() {
+		length += len("$") + len(segment)
+	}
+
+	if options.Checksum {
+		length += len("$") + len(checksumPrefix) + len(checksum(""))
+	}
+
+	return length
+}
+
+// IsNativeKey reports whether the given argon2 key could have been produced by
+// this package: it must use the argon2id variant, version 0x13 and
+// RawURLEncoding as detected by DetectEncoding. Keys of reference tools using
+// RawStdEncoding are reported as not native. Malformed keys return an error.
+func IsNativeKey(key string) (bool, error) {
+	encoding, err := DetectEncoding(key)
+	if err != nil {
+		return false, err
+	}
+
+	decodedKey, version, _, err := parseSegments(key)
+	if err != nil {
+		return false, err
+	}
+
+	native := decodedKey[1] == "argon2id" &&
+		version == argon2.Version &&
+		encoding == base64.RawURLEncoding
+
+	return native, nil
+}
+
+// ReEncode re-emits the salt and hash of the given argon2 key using the target
+// encoding. The source encoding is detected using DetectEncoding. All other
+// segments are kept as they are and a checksum is recomputed if the key had
+// one. argon2 is not run, so it can be used to cheaply migrate stored keys
+// when only the base64 encoding changes. Padded target encodings return
+// ErrPaddedEncoding.
+func ReEncode(key string, target *base64.Encoding) (string, error) {
+	if target == nil {
+		return "", ErrUnknownEncoding
+	}
+
+	if usesPadding(target) {
+		return "", ErrPaddedEncoding
+	}
+
+	if err := checkVariant(key); err != nil {
+		return "", err
+	}
+
+	source, err := DetectEncoding(key)
+	if err != nil {
+		return "", err
+	}
+
+	decodedKey, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return "", err
+	}
+
+	salt, hash, err := decodeSegments(decodedKey, source)
+	if err != nil {
+		return "", err
+	}
+
+	decodedKey[4] = target.EncodeToString(salt)
+	decodedKey[5] = target.EncodeToString(hash)
+	encoded := strings.Join(append(decodedKey, trailing...), "$")
+
+	if strings.Contains(key, "$"+checksumPrefix) {
+		encoded += "$" + checksumPrefix + checksum(encoded)
+	}
+
+	return encoded, nil
+}
+
+// ConvertEncoding converts the given argon2 key between RawURLEncoding, the
+// encoding used by this package, and RawStdEncoding, the encoding of the PHC
+// string format used by most other implementations. It is ReEncode restricted
+// to those two targets, so only the salt and hash are rewritten and argon2 is
+// not run. Any other target returns ErrUnknownEncoding.
+func ConvertEncoding(key string, to *base64.Encoding) (string, error) {
+	if to != base64.RawURLEncoding && to != base64.RawStdEncoding {
+		return "", ErrUnknownEncoding
+	}
+
+	return ReEncode(key, to)
+}
@@ -0,0 +1,243 @@
+package argon2id_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	t.Run("EmptyKey", func(t *testing.T) {
+		if _, err := argon2id.DetectEncoding(""); err != argon2id.ErrArgon2KeyRequired {
+			t.Fatal("Expected ErrArgon2KeyRequired.")
+		}
+	})
+
+	t.Run("WrongLength", func(t *testing.T) {
+		if _, err := argon2id.DetectEncoding("$argon2id$v=19"); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+
+	t.Run("URLEncoding", func(t *testing.T) {
+		if e, err := argon2id.DetectEncoding("$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$-_-_"); err != nil {
+			t.Fatal(err)
+		} else if e != base64.RawURLEncoding {
+			t.Fatal("Expected RawURLEncoding.")
+		}
+	})
+
+	t.Run("StdEncoding", func(t *testing.T) {
+		if e, err := argon2id.DetectEncoding("$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$+/+/"); err != nil {
+			t.Fatal(err)
+		} else if e != base64.RawStdEncoding {
+			t.Fatal("Expected RawStdEncoding.")
+		}
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		if e, err := argon2id.DetectEncoding("$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"); err != nil {
+			t.Fatal(err)
+		} else if e != base64.RawURLEncoding {
+			t.Fatal("Expected RawURLEncoding.")
+		}
+	})
+
+	t.Run("MixedAlphabets", func(t *testing.T) {
+		if _, err := argon2id.DetectEncoding("$argon2id$v=19$m=65536,t=1,p=4$c2Fs-A$+/+/"); err != argon2id.ErrUnknownEncoding {
+			t.Fatal("Expected ErrUnknownEncoding.")
+		}
+	})
+
+	t.Run("InvalidBase64", func(t *testing.T) {
+		if _, err := argon2id.DetectEncoding("$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$*"); err != argon2id.ErrUnknownEncoding {
+			t.Fatal("Expected ErrUnknownEncoding.")
+		}
+	})
+}
+
+func TestEncodedKeyLength(t *testing.T) {
+	for _, o := range []argon2id.Options{
+		{Time: 1, Memory: 8, Threads: 1, KeyLen: 16},
+		{Time: 12, Memory: 1024, Threads: 4, KeyLen: 32},
+		{Time: 3, Memory: 12345, Threads: 255, KeyLen: 33},
+		{Time: 1, Memory: 64, Threads: 8, KeyLen: 64, Checksum: true},
+		{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Nonce: true, Checksum: true},
+		{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Nonce: true, Checksum: true, Label: "vault"},
+	} {
+		for _, saltLen := range []int{1, 4, 16, 17, 32} {
+			o := o
+
+			key, err := argon2id.HashPassword("password", strings.Repeat("s", saltLen), &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if l := argon2id.EncodedKeyLength(&o, uint32(saltLen)); l != len(key) {
+				t.Fatalf("Expected length %d for %q, got %d.", len(key), key, l)
+			}
+		}
+	}
+
+	t.Run("Version", func(t *testing.T) {
+		o := argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Version: 1000}
+		key := argon2id.EncodeKey(&o, []byte("salt"), make([]byte, 32))
+
+		if l := argon2id.EncodedKeyLength(&o, 4); l != len(key) {
+			t.Fatalf("Expected length %d for %q, got %d.", len(key), key, l)
+		}
+	})
+}
+
+func TestIsNativeKey(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		key    string
+		native bool
+	}{
+		{"URLEncoding", "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA", true},
+		{"Ambiguous", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU", true},
+		{"StdEncoding", "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA", false},
+		{"OtherVariant", "$argon2i$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA", false},
+		{"OtherVersion", "$argon2id$v=16$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA", false},
+	} {
+		if native, err := argon2id.IsNativeKey(c.key); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		} else if native != c.native {
+			t.Fatalf("%s: Expected %v.", c.name, c.native)
+		}
+	}
+
+	t.Run("MalformedKey", func(t *testing.T) {
+		for _, key := range []string{"", "$argon2id$v=19", "$argon2id$v=19$m=8,t=1$c2FsdA$c2FsdA", "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$*"} {
+			if _, err := argon2id.IsNativeKey(key); err == nil {
+				t.Fatalf("Expected error for %q.", key)
+			}
+		}
+	})
+}
+
+func TestReEncode(t *testing.T) {
+	urlKey := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+	stdKey := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA"
+
+	t.Run("URLToStd", func(t *testing.T) {
+		key, err := argon2id.ReEncode(urlKey, base64.RawStdEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != stdKey {
+			t.Fatal("Expected standard encoded key.")
+		}
+
+		canonical, err := argon2id.CanonicalizeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", canonical); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("StdToURL", func(t *testing.T) {
+		key, err := argon2id.ReEncode(stdKey, base64.RawURLEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Checksum: true}
+
+		key, err := argon2id.HashPassword("password", "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		converted, err := argon2id.ReEncode(key, base64.RawStdEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		back, err := argon2id.ReEncode(converted, base64.RawURLEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if back != key {
+			t.Fatal("Expected original key.")
+		}
+	})
+
+	t.Run("NilEncoding", func(t *testing.T) {
+		if _, err := argon2id.ReEncode(urlKey, nil); err != argon2id.ErrUnknownEncoding {
+			t.Fatal("Expected ErrUnknownEncoding.")
+		}
+	})
+
+	t.Run("PaddedEncoding", func(t *testing.T) {
+		if _, err := argon2id.ReEncode(urlKey, base64.StdEncoding); err != argon2id.ErrPaddedEncoding {
+			t.Fatal("Expected ErrPaddedEncoding.")
+		}
+	})
+
+	t.Run("EncodeKeyPaddedEncoding", func(t *testing.T) {
+		k, err := argon2id.DecodeKey(urlKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k.Options.Encoding = base64.StdEncoding
+
+		if key := argon2id.EncodeKey(&k.Options, k.Salt, k.Hash); key != stdKey {
+			t.Fatal("Expected unpadded standard encoded key.")
+		}
+	})
+}
+
+func TestConvertEncoding(t *testing.T) {
+	urlKey := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+	stdKey := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA"
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		key, err := argon2id.ConvertEncoding(urlKey, base64.RawStdEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != stdKey {
+			t.Fatal("Expected standard encoded key.")
+		}
+
+		back, err := argon2id.ConvertEncoding(key, base64.RawURLEncoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if back != urlKey {
+			t.Fatal("Expected original key.")
+		}
+	})
+
+	t.Run("OtherEncoding", func(t *testing.T) {
+		for _, enc := range []*base64.Encoding{nil, base64.StdEncoding, base64.URLEncoding.WithPadding(base64.NoPadding)} {
+			if _, err := argon2id.ConvertEncoding(urlKey, enc); err != argon2id.ErrUnknownEncoding {
+				t.Fatal("Expected ErrUnknownEncoding.")
+			}
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.ConvertEncoding("$argon2id$v=19", base64.RawStdEncoding); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+}
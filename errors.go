@@ -0,0 +1,138 @@
+package argon2id
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// sentinelError is the type of the errors exported by this package. The text
+// of an error can be overridden using SetErrorText while its identity stays
+// the same. An error with a parent matches it using errors.Is.
+type sentinelError struct {
+	text   string
+	parent *sentinelError
+}
+
+var (
+	errorTextMu sync.RWMutex
+	errorText   = map[*sentinelError]string{}
+)
+
+// newError returns a new sentinel error with the given default text.
+func newError(text string) error {
+	return &sentinelError{text: text}
+}
+
+// newChildError returns a new sentinel error with the given default text that
+// matches the given parent using errors.Is.
+func newChildError(text string, parent error) error {
+	return &sentinelError{text: text, parent: parent.(*sentinelError)}
+}
+
+// Is reports whether target is one of the parents of the error.
+func (e *sentinelError) Is(target error) bool {
+	for p := e.parent; p != nil; p = p.parent {
+		if p == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Error returns the overridden text of the error, if any, or its default text.
+func (e *sentinelError) Error() string {
+	errorTextMu.RLock()
+	defer errorTextMu.RUnlock()
+
+	if text, ok := errorText[e]; ok {
+		return text
+	}
+
+	return e.text
+}
+
+// SetErrorText overrides the text of one of the errors exported by this
+// package, e.g. to localize it. The error keeps its identity, so comparisons
+// and errors.Is keep working. An empty text restores the default text. Errors
+// not exported by this package are ignored. It is safe for concurrent use.
+func SetErrorText(err error, text string) {
+	e, ok := err.(*sentinelError)
+	if !ok {
+		return
+	}
+
+	errorTextMu.Lock()
+	defer errorTextMu.Unlock()
+
+	if text == "" {
+		delete(errorText, e)
+		return
+	}
+
+	errorText[e] = text
+}
+
+// causeError wraps a sentinel error together with the underlying error that
+// caused it. It matches the sentinel using errors.Is and unwraps to the cause,
+// so the cause can be inspected using errors.As.
+type causeError struct {
+	err   error
+	cause error
+}
+
+// withCause returns the sentinel error err wrapped together with its cause.
+func withCause(err error, cause error) error {
+	return &causeError{err: err, cause: cause}
+}
+
+// Error returns the text of the sentinel error followed by the cause.
+func (e *causeError) Error() string {
+	return e.err.Error() + " " + e.cause.Error()
+}
+
+// Is reports whether the sentinel error matches target.
+func (e *causeError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// Unwrap returns the cause.
+func (e *causeError) Unwrap() error {
+	return e.cause
+}
+
+// VersionError is returned if the version of an argon2 key is not supported.
+// It matches ErrArgonVersionMismatch using errors.Is and carries the version
+// of the key, so callers can decide how to handle legacy keys.
+type VersionError struct {
+	Version int
+}
+
+// Error returns the text of ErrArgonVersionMismatch followed by the version.
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s Version %d is not supported.", ErrArgonVersionMismatch, e.Version)
+}
+
+// Is reports whether target is ErrArgonVersionMismatch.
+func (e *VersionError) Is(target error) bool {
+	return target == ErrArgonVersionMismatch
+}
+
+// BatchError is returned by VerifyPasswordAny if none of the keys match and
+// some of them could not be verified. It matches ErrHashNotEqualPassword using
+// errors.Is and maps the indices of those keys to their errors.
+type BatchError struct {
+	Errors map[int]error
+}
+
+// Error returns the text of ErrHashNotEqualPassword followed by the number of
+// keys that could not be verified.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s %d keys could not be verified.", ErrHashNotEqualPassword, len(e.Errors))
+}
+
+// Is reports whether target is ErrHashNotEqualPassword.
+func (e *BatchError) Is(target error) bool {
+	return target == ErrHashNotEqualPassword
+}
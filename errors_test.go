@@ -0,0 +1,174 @@
+package argon2id_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestSetErrorText(t *testing.T) {
+	defer argon2id.SetErrorText(argon2id.ErrHashNotEqualPassword, "")
+
+	original := argon2id.ErrHashNotEqualPassword.Error()
+	argon2id.SetErrorText(argon2id.ErrHashNotEqualPassword, "argon2id: Passwort stimmt nicht überein.")
+
+	t.Run("Text", func(t *testing.T) {
+		if argon2id.ErrHashNotEqualPassword.Error() != "argon2id: Passwort stimmt nicht überein." {
+			t.Fatal("Expected overridden text.")
+		}
+	})
+
+	t.Run("Identity", func(t *testing.T) {
+		// password:salt
+		key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+		err := argon2id.VerifyPassword("password1", key)
+		if err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if err.Error() != "argon2id: Passwort stimmt nicht überein." {
+			t.Fatal("Expected overridden text.")
+		}
+
+		if !errors.Is(fmt.Errorf("login: %w", err), argon2id.ErrHashNotEqualPassword) {
+			t.Fatal("Expected wrapped error to match.")
+		}
+	})
+
+	t.Run("OtherErrors", func(t *testing.T) {
+		if argon2id.ErrPasswordRequired.Error() != "argon2id: password must not be empty." {
+			t.Fatal("Expected default text.")
+		}
+
+		argon2id.SetErrorText(errors.New("foreign"), "ignored")
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		argon2id.SetErrorText(argon2id.ErrHashNotEqualPassword, "")
+
+		if argon2id.ErrHashNotEqualPassword.Error() != original {
+			t.Fatal("Expected default text.")
+		}
+	})
+}
+
+func TestErrInvalidKeyFormat(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	for _, c := range []struct {
+		name string
+		key  string
+		err  error
+	}{
+		{"EmptyKey", "", argon2id.ErrArgon2KeyRequired},
+		{"WrongAlgorithm", "$2a$10$abcdefghijklmnopqrstuv", argon2id.ErrWrongAlgorithm},
+		{"KeyLength", "$argon2id$v=19", argon2id.ErrInvalidKeyLength},
+		{"Version", "$argon2id$v=x$m=65536,t=1,p=4$c2FsdA$c2FsdA", argon2id.ErrInvalidVersion},
+		{"Parameters", "$argon2id$v=19$m=x,t=1,p=4$c2FsdA$c2FsdA", argon2id.ErrInvalidParameters},
+		{"Base64", "$argon2id$v=19$m=65536,t=1,p=4$c2F*dA$c2FsdA", argon2id.ErrInvalidBase64},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			err := argon2id.VerifyPassword("password", c.key)
+
+			if !errors.Is(err, c.err) {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+
+			if !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+				t.Fatal("Expected ErrInvalidKeyFormat.")
+			}
+		})
+	}
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password1", key); errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Did not expect ErrInvalidKeyFormat.")
+		}
+	})
+
+	t.Run("Base64Cause", func(t *testing.T) {
+		err := argon2id.VerifyPassword("password", "$argon2id$v=19$m=65536,t=1,p=4$c2F*dA$c2FsdA")
+
+		var corrupt base64.CorruptInputError
+		if !errors.As(err, &corrupt) {
+			t.Fatal("Expected base64.CorruptInputError.")
+		}
+	})
+}
+
+func TestErrCorruptKey(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	for _, c := range []struct {
+		name string
+		key  string
+	}{
+		{"EmptyKey", ""},
+		{"KeyLength", "$argon2id$v=19"},
+		{"Base64", "$argon2id$v=19$m=65536,t=1,p=4$c2F*dA$c2FsdA"},
+		{"Padding", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA==$c2FsdA"},
+		{"HashLength", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$c2FsdA"},
+		{"Checksum", key + "$crc=00000000"},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			err := argon2id.VerifyPassword("password", c.key)
+
+			if !errors.Is(fmt.Errorf("login: %w", err), argon2id.ErrCorruptKey) {
+				t.Fatalf("Expected ErrCorruptKey, got %v.", err)
+			}
+
+			if errors.Is(err, argon2id.ErrHashNotEqualPassword) {
+				t.Fatal("Did not expect ErrHashNotEqualPassword.")
+			}
+		})
+	}
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		err := argon2id.VerifyPassword("password1", key)
+		if err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if errors.Is(err, argon2id.ErrCorruptKey) {
+			t.Fatal("Did not expect ErrCorruptKey.")
+		}
+	})
+}
+
+func TestVersionError(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=16$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	err := argon2id.VerifyPassword("password", key)
+
+	t.Run("Is", func(t *testing.T) {
+		if !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+			t.Fatal("Expected ErrArgonVersionMismatch.")
+		}
+	})
+
+	t.Run("As", func(t *testing.T) {
+		var versionErr *argon2id.VersionError
+		if !errors.As(err, &versionErr) {
+			t.Fatal("Expected VersionError.")
+		}
+
+		if versionErr.Version != 16 {
+			t.Fatal("Expected version 16.")
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		if argon2id.Version != 0x13 {
+			t.Fatal("Expected version 0x13.")
+		}
+	})
+}
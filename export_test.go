@@ -0,0 +1,30 @@
+package argon2id
+
+import (
+	"io"
+)
+
+// CompareHash exposes compareHash to the tests.
+var CompareHash = compareHash
+
+// SetWipeHook sets the function that is called with every wiped buffer and
+// returns a function restoring the previous hook.
+func SetWipeHook(f func(b []byte)) func() {
+	previous := wipeHook
+	wipeHook = f
+
+	return func() {
+		wipeHook = previous
+	}
+}
+
+// SetRandReader sets the source of random bytes for salts and returns a
+// function restoring the previous one.
+func SetRandReader(r io.Reader) func() {
+	previous := randReader
+	randReader = r
+
+	return func() {
+		randReader = previous
+	}
+}
@@ -0,0 +1,64 @@
+//go:build go1.18
+// +build go1.18
+
+package argon2id_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+// fuzzErrors are the errors documented for VerifyPassword. Every error it
+// returns must match one of them.
+var fuzzErrors = []error{
+	argon2id.ErrHashNotEqualPassword,
+	argon2id.ErrCorruptKey,
+	argon2id.ErrArgonVersionMismatch,
+	argon2id.ErrUnsupportedVariant,
+	argon2id.ErrPasswordRequired,
+	argon2id.ErrPasswordTooLong,
+	argon2id.ErrWrappedKey,
+}
+
+func FuzzVerifyPassword(f *testing.F) {
+	for _, key := range []string{
+		"",
+		"$",
+		"$$$$$",
+		"$$$$$$",
+		"$argon2id$v=19",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA$crc=00000000",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA$n=AAAA$keyid=1",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA",
+		"$argon2i$v=19$m=8,t=1,p=1$c2FsdA$AAAAAAAAAAAAAAAAAAAAAA",
+		"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+	} {
+		f.Add(key)
+	}
+
+	f.Fuzz(func(t *testing.T, key string) {
+		// Keys with expensive parameters are valid input but would make the
+		// fuzzer spend its time and memory in argon2.
+		if options, _, _, err := argon2id.ParseKey(key); err == nil {
+			if options.Memory > 1024 || options.Time > 4 || options.Threads > 4 {
+				t.Skip()
+			}
+		}
+
+		err := argon2id.VerifyPassword("password", key)
+		if err == nil {
+			return
+		}
+
+		for _, documented := range fuzzErrors {
+			if errors.Is(err, documented) {
+				return
+			}
+		}
+
+		t.Fatalf("Undocumented error %v for key %q.", err, key)
+	})
+}
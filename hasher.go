@@ -0,0 +1,83 @@
+package argon2id
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	// bootstrapTarget is the duration a single hash should take using the
+	// options chosen by Bootstrap.
+	bootstrapTarget = 250 * time.Millisecond
+
+	// bootstrapMaxMemory is the maximum memory in KiB Bootstrap will use.
+	bootstrapMaxMemory = 256 * 1024
+
+	// bootstrapMaxThreads is the maximum number of threads Bootstrap will use.
+	bootstrapMaxThreads = 4
+)
+
+// Hasher hashes and verifies passwords using the options it was created
+// with, so they do not have to be passed to every call.
+type Hasher struct {
+	options *Options
+}
+
+// NewHasher returns a Hasher using the given options. The options are
+// validated using Options.Validate and copied, so later changes to them do
+// not affect the Hasher.
+func NewHasher(options *Options) (*Hasher, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	o := *options
+	o.Secret = append([]byte(nil), options.Secret...)
+
+	return &Hasher{options: &o}, nil
+}
+
+// Hash takes a password and a salt and returns an argon2 key using the
+// options of the Hasher. It is safe for concurrent use.
+func (h *Hasher) Hash(password string, salt string) (string, error) {
+	return HashPassword(password, salt, h.options)
+}
+
+// Verify takes a password and an argon2 key and compares both, applying the
+// Secret of the options of the Hasher. It will return an error if they are not
+// equal. It is safe for concurrent use.
+func (h *Hasher) Verify(password string, key string) error {
+	return VerifyPasswordWithSecret(password, key, h.options.Secret)
+}
+
+// Bootstrap calibrates options so a single hash takes about 250ms on the
+// current host, using at most 256 MiB and up to 4 threads, and returns a
+// Hasher using them together with the argon2 key of the admin password,
+// hashed with a random salt. It is meant to set up new deployments.
+func Bootstrap(adminPassword string) (*Hasher, string, error) {
+	if adminPassword == "" {
+		return nil, "", ErrPasswordRequired
+	}
+
+	threads := runtime.NumCPU()
+	if threads > bootstrapMaxThreads {
+		threads = bootstrapMaxThreads
+	}
+
+	options, err := Calibrate(bootstrapTarget, bootstrapMaxMemory, uint8(threads))
+	if err != nil {
+		return nil, "", err
+	}
+
+	hasher, err := NewHasher(options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	adminKey, err := HashPasswordWithRandomSalt(adminPassword, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return hasher, adminKey, nil
+}
@@ -0,0 +1,79 @@
+package argon2id_test
+
+import (
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestHasher(t *testing.T) {
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := argon2id.NewHasher(nil); err != argon2id.ErrInvalidOptions {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+
+		if _, err := argon2id.NewHasher(&argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 2}); err != argon2id.ErrInvalidKeyLen {
+			t.Fatal("Expected ErrInvalidKeyLen.")
+		}
+	})
+
+	options := argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	h, err := argon2id.NewHasher(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the options afterwards must not affect the hasher.
+	options.Memory = 16
+
+	key, err := h.Hash("password", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Hash", func(t *testing.T) {
+		if key != "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA" {
+			t.Fatal("Expected pre-defined hash.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := h.Verify("password", key); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := h.Verify("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestBootstrap(t *testing.T) {
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, err := argon2id.Bootstrap(""); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	if testing.Short() {
+		t.Skip("Skipping calibration in short mode.")
+	}
+
+	hasher, adminKey, err := argon2id.Bootstrap("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := hasher.Verify("admin", adminKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := hasher.Verify("wrong", adminKey); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package argon2id
+
+import (
+	"context"
+	"sync"
+)
+
+// MatchesAny reports whether the password verifies against any of the given
+// historical argon2 keys. It can be used to prevent users from reusing one of
+// their previous passwords. Every key is verified using its own parameters,
+// keys that are malformed are skipped as they can not match any password.
+func MatchesAny(newPassword string, historyKeys []string) (bool, error) {
+	if newPassword == "" {
+		return false, ErrPasswordRequired
+	}
+
+	for _, key := range historyKeys {
+		if err := VerifyPassword(newPassword, key); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// VerifyPasswordAny verifies the password against the given argon2 keys in
+// order, e.g. the hashes of a user from before and after a migration, and
+// returns the index of the first key that matches. Keys that can not be
+// verified are skipped. If no key matches it returns -1 and
+// ErrHashNotEqualPassword, or a *BatchError holding the errors of the skipped
+// keys, which also matches ErrHashNotEqualPassword using errors.Is.
+func VerifyPasswordAny(password string, keys []string) (int, error) {
+	if password == "" {
+		return -1, ErrPasswordRequired
+	}
+
+	var errs map[int]error
+
+	for i, key := range keys {
+		err := VerifyPassword(password, key)
+		if err == nil {
+			return i, nil
+		}
+
+		if err != ErrHashNotEqualPassword {
+			if errs == nil {
+				errs = map[int]error{}
+			}
+
+			errs[i] = err
+		}
+	}
+
+	if errs != nil {
+		return -1, &BatchError{Errors: errs}
+	}
+
+	return -1, ErrHashNotEqualPassword
+}
+
+// CompareKeys reports whether the password verifies against both argon2
+// keys, e.g. to check that two systems hashed the same credential. Comparing
+// the keys themselves is meaningless as their salts differ. Both keys are
+// always verified, so the time taken does not reveal which one did not
+// match. It returns an error if either key can not be parsed.
+func CompareKeys(password string, keyA string, keyB string) (bool, error) {
+	if password == "" {
+		return false, ErrPasswordRequired
+	}
+
+	a, err := DecodeKey(keyA)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := DecodeKey(keyB)
+	if err != nil {
+		return false, err
+	}
+
+	defer wipe(a.Salt, a.Hash, b.Salt, b.Hash)
+
+	matchA := a.Verify(password) == nil
+	matchB := b.Verify(password) == nil
+
+	return matchA && matchB, nil
+}
+
+// CountMatches verifies the password against every given argon2 key using
+// the given number of workers and returns how many and which keys match,
+// with the indices in ascending order. It can be used to check whether a
+// leaked password is in use by any user. Malformed keys never match.
+func CountMatches(password string, keys []string, workers int) (int, []int, error) {
+	return CountMatchesContext(context.Background(), password, keys, workers)
+}
+
+// CountMatchesContext works like CountMatches but stops and returns the
+// context's error once the context is done.
+func CountMatchesContext(ctx context.Context, password string, keys []string, workers int) (int, []int, error) {
+	if password == "" {
+		return 0, nil, ErrPasswordRequired
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	indices := make(chan int)
+	matched := make([]bool, len(keys))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				matched[i] = VerifyPassword(password, keys[i]) == nil
+			}
+		}()
+	}
+
+	var err error
+
+feed:
+	for i := range keys {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+
+	close(indices)
+	wg.Wait()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	matches := []int{}
+	for i, ok := range matched {
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+
+	return len(matches), matches, nil
+}
@@ -0,0 +1,247 @@
+package argon2id_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestMatchesAny(t *testing.T) {
+	weak := &argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 16}
+
+	older, err := argon2id.HashPassword("oldpassword", "salt1", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := []string{
+		"",
+		"$argon2id$v=19",
+		older,
+		"$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU",
+	}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.MatchesAny("", history); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("ReusedPassword", func(t *testing.T) {
+		if ok, err := argon2id.MatchesAny("password", history); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("Expected match.")
+		}
+	})
+
+	t.Run("ReusedOlderPassword", func(t *testing.T) {
+		if ok, err := argon2id.MatchesAny("oldpassword", history); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("Expected match.")
+		}
+	})
+
+	t.Run("FreshPassword", func(t *testing.T) {
+		if ok, err := argon2id.MatchesAny("freshpassword", history); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("Did not expect match.")
+		}
+	})
+
+	t.Run("EmptyHistory", func(t *testing.T) {
+		if ok, err := argon2id.MatchesAny("password", nil); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("Did not expect match.")
+		}
+	})
+}
+
+func TestVerifyPasswordAny(t *testing.T) {
+	weak := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	older, err := argon2id.HashPassword("oldpassword", "salt1", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newer, err := argon2id.HashPassword("newpassword", "salt2", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordAny("", []string{older}); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		} else if i != -1 {
+			t.Fatal("Expected index -1.")
+		}
+	})
+
+	t.Run("FirstMatch", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordAny("oldpassword", []string{newer, older, older}); err != nil {
+			t.Fatal(err)
+		} else if i != 1 {
+			t.Fatal("Expected index 1.")
+		}
+	})
+
+	t.Run("SkipsMalformedKeys", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordAny("newpassword", []string{"", "$argon2id$v=19", newer}); err != nil {
+			t.Fatal(err)
+		} else if i != 2 {
+			t.Fatal("Expected index 2.")
+		}
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordAny("password", []string{older, newer}); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		} else if i != -1 {
+			t.Fatal("Expected index -1.")
+		}
+	})
+
+	t.Run("NoKeys", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordAny("password", nil); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		} else if i != -1 {
+			t.Fatal("Expected index -1.")
+		}
+	})
+
+	t.Run("BatchError", func(t *testing.T) {
+		i, err := argon2id.VerifyPasswordAny("password", []string{older, "", "$argon2id$v=19", newer})
+		if i != -1 {
+			t.Fatal("Expected index -1.")
+		}
+
+		if !errors.Is(err, argon2id.ErrHashNotEqualPassword) {
+			t.Fatal("Expected error matching ErrHashNotEqualPassword.")
+		}
+
+		var batchErr *argon2id.BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatal("Expected BatchError.")
+		}
+
+		if len(batchErr.Errors) != 2 {
+			t.Fatal("Expected errors of 2 keys.")
+		}
+
+		if !errors.Is(batchErr.Errors[1], argon2id.ErrInvalidKeyFormat) || !errors.Is(batchErr.Errors[2], argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat for malformed keys.")
+		}
+	})
+}
+
+func TestCompareKeys(t *testing.T) {
+	weak := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	keyA, err := argon2id.HashPassword("password", "salt1", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyB, err := argon2id.HashPassword("password", "salt2", shortSaltOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := argon2id.HashPassword("other", "salt3", weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.CompareKeys("", keyA, keyB); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("SameCredential", func(t *testing.T) {
+		if ok, err := argon2id.CompareKeys("password", keyA, keyB); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("Expected match.")
+		}
+	})
+
+	t.Run("DifferentCredential", func(t *testing.T) {
+		if ok, err := argon2id.CompareKeys("password", keyA, other); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("Did not expect match.")
+		}
+
+		if ok, err := argon2id.CompareKeys("other", keyA, other); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Fatal("Did not expect match.")
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.CompareKeys("password", keyA, "$argon2id$v=19"); !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+	})
+}
+
+func TestCountMatches(t *testing.T) {
+	weak := &argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 16}
+
+	keys := make([]string, 0, 6)
+	for _, c := range []struct{ password, salt string }{
+		{"leaked", "salt1"},
+		{"other", "salt2"},
+		{"leaked", "salt3"},
+		{"leaked", "salt4"},
+	} {
+		key, err := argon2id.HashPassword(c.password, c.salt, weak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	keys = append(keys, "$argon2id$v=19", "")
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, err := argon2id.CountMatches("", keys, 2); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	for _, workers := range []int{0, 1, 3, 10} {
+		if n, matches, err := argon2id.CountMatches("leaked", keys, workers); err != nil {
+			t.Fatal(err)
+		} else if n != 3 || !reflect.DeepEqual(matches, []int{0, 2, 3}) {
+			t.Fatalf("Expected matches 0, 2 and 3 with %d workers, got %v.", workers, matches)
+		}
+	}
+
+	t.Run("NoMatches", func(t *testing.T) {
+		if n, matches, err := argon2id.CountMatches("unused", keys, 2); err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(matches) != 0 {
+			t.Fatal("Did not expect matches.")
+		}
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, _, err := argon2id.CountMatchesContext(ctx, "leaked", keys, 1); err != context.Canceled {
+			t.Fatal("Expected context.Canceled.")
+		}
+	})
+}
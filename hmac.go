@@ -0,0 +1,125 @@
+package argon2id
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// hmacHash returns HMAC-SHA256(serverKey, hash) truncated to the length of
+// the hash.
+func hmacHash(serverKey []byte, hash []byte) []byte {
+	mac := hmac.New(sha256.New, serverKey)
+	mac.Write(hash)
+
+	return mac.Sum(nil)[:len(hash)]
+}
+
+// HashPasswordHMAC works like HashPassword but stores
+// HMAC-SHA256(serverKey, argon2 output) instead of the argon2 output itself,
+// so a leaked database alone can not be used to brute force passwords. The
+// HMAC is truncated to the key length of the options, which therefore must
+// not exceed 32 bytes. Rotating the server key invalidates every key created
+// with the previous one. The server key takes the place of Options.Secret,
+// ErrUnsupportedOption is returned if it is set.
+func HashPasswordHMAC(password string, salt string, serverKey []byte, options *Options) (string, error) {
+	if password == "" {
+		return "", ErrPasswordRequired
+	}
+
+	if salt == "" {
+		return "", ErrSaltRequired
+	}
+
+	if len(serverKey) == 0 {
+		return "", ErrSecretRequired
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return "", err
+	}
+
+	options = optionsOrDefault(options)
+
+	if len(options.Secret) > 0 {
+		return "", ErrUnsupportedOption
+	}
+
+	if options.KeyLen > sha256.Size {
+		return "", ErrInvalidHMACKeyLen
+	}
+
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+
+	if err := options.checkSaltLength([]byte(salt)); err != nil {
+		return "", err
+	}
+
+	trailing, err := newTrailingSegments(options)
+	if err != nil {
+		return "", err
+	}
+
+	if options.Label != "" {
+		password = contextPassword(options.Label, password)
+	}
+
+	hash := argon2.IDKey(
+		[]byte(password), []byte(salt),
+		options.Time, options.Memory, options.Threads, options.KeyLen,
+	)
+
+	return encodeKey(options, []byte(salt), hmacHash(serverKey, hash), trailing...), nil
+}
+
+// VerifyPasswordHMAC takes a password, an argon2 key created by
+// HashPasswordHMAC and the server key and compares them. It will return an
+// error if they are not equal.
+func VerifyPasswordHMAC(password string, key string, serverKey []byte) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if len(serverKey) == 0 {
+		return ErrSecretRequired
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if p.wrapped {
+		return ErrWrappedKey
+	}
+
+	if p.KeyLen > sha256.Size {
+		return ErrInvalidHMACKeyLen
+	}
+
+	if p.Label != "" {
+		password = contextPassword(p.Label, password)
+	}
+
+	control := argon2.IDKey(
+		[]byte(password), salt,
+		p.Time, p.Memory, p.Threads, p.KeyLen,
+	)
+
+	defer wipe(control)
+
+	if compareHash(hash, hmacHash(serverKey, control)) {
+		return nil
+	}
+
+	return ErrHashNotEqualPassword
+}
@@ -0,0 +1,93 @@
+package argon2id_test
+
+import (
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestHashPasswordHMAC(t *testing.T) {
+	serverKey := []byte("serverkey")
+
+	t.Run("EmptyServerKey", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordHMAC("password", "salt", nil, argon2id.DefaultOptions); err != argon2id.ErrSecretRequired {
+			t.Fatal("Expected ErrSecretRequired.")
+		}
+	})
+
+	t.Run("KeyLenTooLong", func(t *testing.T) {
+		options := *argon2id.DefaultOptions
+		options.KeyLen = 64
+
+		if _, err := argon2id.HashPasswordHMAC("password", "salt", serverKey, &options); err != argon2id.ErrInvalidHMACKeyLen {
+			t.Fatal("Expected ErrInvalidHMACKeyLen.")
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		options := *shortSaltOptions
+		options.Secret = []byte("pepper")
+
+		if _, err := argon2id.HashPasswordHMAC("password", "salt", serverKey, &options); err != argon2id.ErrUnsupportedOption {
+			t.Fatal("Expected ErrUnsupportedOption.")
+		}
+	})
+
+	t.Run("DiffersFromPlainHash", func(t *testing.T) {
+		key, err := argon2id.HashPasswordHMAC("password", "salt", serverKey, shortSaltOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestVerifyPasswordHMAC(t *testing.T) {
+	serverKey := []byte("serverkey")
+
+	key, err := argon2id.HashPasswordHMAC("password", "salt", serverKey, shortSaltOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EmptyServerKey", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHMAC("password", key, nil); err != argon2id.ErrSecretRequired {
+			t.Fatal("Expected ErrSecretRequired.")
+		}
+	})
+
+	t.Run("CorrectKey", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHMAC("password", key, serverKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongServerKey", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHMAC("password", key, []byte("otherkey")); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHMAC("password1", key, serverKey); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("ShortKeyLen", func(t *testing.T) {
+		options := *shortSaltOptions
+		options.KeyLen = 16
+
+		key, err := argon2id.HashPasswordHMAC("password", "salt", serverKey, &options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPasswordHMAC("password", key, serverKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
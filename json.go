@@ -0,0 +1,135 @@
+package argon2id
+
+import (
+	"encoding/json"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// UnmarshalKeyJSON extracts an argon2 key from the given JSON string value and
+// validates its structure. It returns ErrKeyNotJSONString if the value is not
+// a string and the parsing error if the string is not a valid argon2 key.
+func UnmarshalKeyJSON(data []byte) (string, error) {
+	var key string
+
+	if err := json.Unmarshal(data, &key); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return "", ErrKeyNotJSONString
+		}
+
+		return "", err
+	}
+
+	if _, _, _, err := parseKey(key); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// jsonKey is the JSON form of an argon2 key used by HashPasswordJSON and
+// VerifyPasswordJSON. Salt and hash are encoded using EncodeToBase64String.
+type jsonKey struct {
+	Version int    `json:"v"`
+	Memory  uint32 `json:"m"`
+	Time    uint32 `json:"t"`
+	Threads uint8  `json:"p"`
+	Salt    string `json:"salt"`
+	Hash    string `json:"hash"`
+}
+
+// HashPasswordJSON works like HashPassword but returns the argon2 key as a
+// JSON object instead of a PHC string, e.g.
+// {"v":19,"m":65536,"t":1,"p":4,"salt":"...","hash":"..."}. The JSON form
+// can neither carry a secret nor a label, so it returns ErrUnsupportedOption
+// if Options.Secret or Options.Label is set.
+func HashPasswordJSON(password string, salt string, options *Options) ([]byte, error) {
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	if salt == "" {
+		return nil, ErrSaltRequired
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return nil, err
+	}
+
+	options = optionsOrDefault(options)
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := options.checkSaltLength([]byte(salt)); err != nil {
+		return nil, err
+	}
+
+	if len(options.Secret) > 0 || options.Label != "" {
+		return nil, ErrUnsupportedOption
+	}
+
+	hash := argon2.IDKey(
+		[]byte(password), []byte(salt),
+		options.Time, options.Memory, options.Threads, options.KeyLen,
+	)
+
+	return json.Marshal(jsonKey{
+		Version: options.version(),
+		Memory:  options.Memory,
+		Time:    options.Time,
+		Threads: options.Threads,
+		Salt:    EncodeToBase64String([]byte(salt)),
+		Hash:    EncodeToBase64String(hash),
+	})
+}
+
+// VerifyPasswordJSON takes a password and an argon2 key in the JSON form
+// returned by HashPasswordJSON and compares both. It will return an error if
+// they are not equal.
+func VerifyPasswordJSON(password string, data []byte) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	var k jsonKey
+	if err := json.Unmarshal(data, &k); err != nil {
+		return err
+	}
+
+	if k.Version != argon2.Version {
+		return &VersionError{Version: k.Version}
+	}
+
+	// argon2 panics if time or threads are zero.
+	if k.Time < 1 || k.Threads < 1 {
+		return ErrInvalidParameters
+	}
+
+	salt, err := DecodeBase64String(k.Salt)
+	if err != nil {
+		return err
+	}
+
+	hash, err := DecodeBase64String(k.Hash)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if err := checkHashLength(hash); err != nil {
+		return err
+	}
+
+	p := &Options{
+		Time:    k.Time,
+		Memory:  k.Memory,
+		Threads: k.Threads,
+		KeyLen:  uint32(len(hash)),
+	}
+
+	return verifyKey(password, p, salt, hash)
+}
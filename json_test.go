@@ -0,0 +1,149 @@
+package argon2id_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestUnmarshalKeyJSON(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("ValidKey", func(t *testing.T) {
+		if k, err := argon2id.UnmarshalKeyJSON([]byte(`"` + key + `"`)); err != nil {
+			t.Fatal(err)
+		} else if k != key {
+			t.Fatal("Expected pre-defined key.")
+		}
+	})
+
+	t.Run("Number", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`42`)); err != argon2id.ErrKeyNotJSONString {
+			t.Fatal("Expected ErrKeyNotJSONString.")
+		}
+	})
+
+	t.Run("Object", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`{"key":"` + key + `"}`)); err != argon2id.ErrKeyNotJSONString {
+			t.Fatal("Expected ErrKeyNotJSONString.")
+		}
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`"` + key)); err == nil {
+			t.Fatal("Expected error.")
+		}
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`null`)); err != argon2id.ErrArgon2KeyRequired {
+			t.Fatal("Expected ErrArgon2KeyRequired.")
+		}
+	})
+
+	t.Run("MalformedKey", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`"$argon2id$v=19"`)); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`"$argon2id$v=1$m=65536,t=1,p=4$c2FsdA$c2FsdA"`)); !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+			t.Fatal("Expected ErrArgonVersionMismatch.")
+		}
+	})
+
+	t.Run("InvalidBase64", func(t *testing.T) {
+		if _, err := argon2id.UnmarshalKeyJSON([]byte(`"$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$*"`)); err == nil {
+			t.Fatal("Expected error.")
+		}
+	})
+}
+
+func TestPasswordJSON(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	data, err := argon2id.HashPasswordJSON("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordJSON("password", data); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordJSON("wrong", data); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("JSONToPHC", func(t *testing.T) {
+		var fields struct {
+			V    int    `json:"v"`
+			M    uint32 `json:"m"`
+			T    uint32 `json:"t"`
+			P    uint8  `json:"p"`
+			Salt string `json:"salt"`
+			Hash string `json:"hash"`
+		}
+
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+
+		phc := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", fields.V, fields.M, fields.T, fields.P, fields.Salt, fields.Hash)
+		if phc != key {
+			t.Fatal("Expected JSON and PHC forms to match.")
+		}
+	})
+
+	t.Run("PHCToJSON", func(t *testing.T) {
+		segments := strings.Split(key, "$")
+		converted := fmt.Sprintf(`{"v":19,"m":8,"t":1,"p":1,"salt":%q,"hash":%q}`, segments[4], segments[5])
+
+		if err := argon2id.VerifyPasswordJSON("password", []byte(converted)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordJSON("password", []byte(`{"v":16,"m":8,"t":1,"p":1,"salt":"c2FsdA","hash":"c2FsdA"}`)); !errors.Is(err, argon2id.ErrArgonVersionMismatch) {
+			t.Fatal("Expected ErrArgonVersionMismatch.")
+		}
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordJSON("password", []byte(`{"v":19,"m":8,"t":0,"p":1,"salt":"c2FsdA","hash":"c2FsdA"}`)); err != argon2id.ErrInvalidParameters {
+			t.Fatal("Expected ErrInvalidParameters.")
+		}
+	})
+	t.Run("Secret", func(t *testing.T) {
+		o := *options
+		o.Secret = []byte("pepper")
+
+		if _, err := argon2id.HashPasswordJSON("password", "salt", &o); err != argon2id.ErrUnsupportedOption {
+			t.Fatal("Expected ErrUnsupportedOption.")
+		}
+	})
+
+	t.Run("Label", func(t *testing.T) {
+		o := *options
+		o.Label = "vault"
+
+		if _, err := argon2id.HashPasswordJSON("password", "salt", &o); err != argon2id.ErrUnsupportedOption {
+			t.Fatal("Expected ErrUnsupportedOption.")
+		}
+	})
+}
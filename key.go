@@ -0,0 +1,347 @@
+package argon2id
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// maskedSegment replaces sensitive segments in masked keys.
+const maskedSegment = "***"
+
+// MaskKey returns the given argon2 key with its salt, hash and every segment
+// following them replaced by "***", e.g.
+// "$argon2id$v=19$m=65536,t=1,p=4$***$***". The parameters stay visible so
+// the result can safely be logged. Keys that do not contain a salt segment
+// are masked entirely.
+func MaskKey(key string) string {
+	segments := strings.Split(key, "$")
+	if len(segments) < 5 {
+		return maskedSegment
+	}
+
+	for i := 4; i < len(segments); i++ {
+		segments[i] = maskedSegment
+	}
+
+	return strings.Join(segments, "$")
+}
+
+// ParseKey parses the given argon2 key and returns the options it was created
+// with, its decoded salt and its decoded hash. KeyLen is taken from the length
+// of the hash. It uses the same parsing as VerifyPassword and returns
+// ErrInvalidKeyLength, ErrInvalidVersion or ErrInvalidParameters if the
+// segments, version or parameters of the key are malformed and an error
+// matching ErrInvalidBase64 if its base64 is. All of them match
+// ErrInvalidKeyFormat.
+func ParseKey(key string) (*Options, []byte, []byte, error) {
+	return parseKey(key)
+}
+
+// EncodeKey returns the argon2 key for the given options, salt and hash, as
+// written by HashPassword. It is the counterpart of ParseKey and can be used
+// to build keys from material derived elsewhere. Salt and hash are encoded
+// using Options.Encoding and a checksum is appended if Options.Checksum is set.
+// Options.Label is kept, Options.Nonce is ignored.
+func EncodeKey(options *Options, salt []byte, hash []byte) string {
+	options = optionsOrDefault(options)
+	return encodeKey(options, salt, hash, options.labelSegments()...)
+}
+
+// SaltLenOf returns the length in bytes of the decoded salt of the given
+// argon2 key. It can be used to generate a new salt of the same length when
+// rehashing.
+func SaltLenOf(key string) (int, error) {
+	_, salt, _, err := parseKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(salt), nil
+}
+
+// CanonicalizeKey re-emits the given argon2 key in the canonical form written
+// by HashPassword: parameters in "m,t,p" order and salt and hash in
+// RawURLEncoding. A checksum and the optional segments following the hash are
+// kept if the key had them. It can be used to normalize stored keys in bulk.
+func CanonicalizeKey(key string) (string, error) {
+	encoding, err := DetectEncoding(key)
+	if err != nil {
+		return "", err
+	}
+
+	options, salt, hash, err := parseKeyWithEncoding(key, encoding)
+	if err != nil {
+		return "", err
+	}
+
+	_, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return "", err
+	}
+
+	options.Checksum = strings.Contains(key, "$"+checksumPrefix)
+
+	return encodeKey(options, salt, hash, trailing...), nil
+}
+
+// Key is a parsed argon2 key.
+type Key struct {
+	// Variant is the argon2 variant of the key, e.g. "argon2id".
+	Variant string
+
+	// Version is the argon2 version of the key.
+	Version int
+
+	// Options are the options the key was created with. KeyLen is taken from
+	// the length of the hash and Checksum is set if the key has a checksum.
+	Options Options
+
+	// Salt is the decoded salt of the key.
+	Salt []byte
+
+	// Hash is the decoded hash of the key.
+	Hash []byte
+
+	// Trailing are the optional segments following the hash, like a nonce, a
+	// key id or the "layered=legacy" marker of WrapExistingHash. The label is
+	// held by Options.Label and the checksum by Options.Checksum, so neither
+	// is part of it.
+	Trailing []string
+}
+
+// DecodeKey parses the given argon2 key. It accepts the same keys as
+// VerifyPassword and returns the same errors for malformed keys, so String
+// returns the key again. Options.Encoding is set to RawStdEncoding for keys
+// written in the standard base64 alphabet. Keys mixing both alphabets can not
+// be written again and return ErrUnknownEncoding. Use Inspect to parse keys of
+// other variants and versions.
+func DecodeKey(key string) (*Key, error) {
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := DetectEncoding(key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Checksum = strings.Contains(key, "$"+checksumPrefix)
+	if encoding != base64.RawURLEncoding {
+		p.Encoding = encoding
+	}
+
+	return &Key{
+		Variant:  "argon2id",
+		Version:  argon2.Version,
+		Options:  *p,
+		Salt:     salt,
+		Hash:     hash,
+		Trailing: unlabeledSegments(trailing),
+	}, nil
+}
+
+// unlabeledSegments returns the given trailing segments of a key without the
+// label segment, or nil if no other segment is left.
+func unlabeledSegments(trailing []string) []string {
+	var segments []string
+	for _, segment := range trailing {
+		if !strings.HasPrefix(segment, labelPrefix) {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+// Verify takes a password and compares it to the key. It will return an error
+// if they are not equal. Keys returned by Inspect or DecodeBinary may hold any
+// variant, version and parameters, so they are checked like VerifyPassword
+// does before argon2 is run. KeyLen is taken from the length of the hash.
+func (k *Key) Verify(password string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if k.Variant != "argon2id" {
+		return ErrUnsupportedVariant
+	}
+
+	if k.Version != argon2.Version {
+		return &VersionError{Version: k.Version}
+	}
+
+	// argon2 panics if time or threads are zero.
+	if k.Options.Time < 1 || k.Options.Threads < 1 {
+		return ErrInvalidParameters
+	}
+
+	if err := checkHashLength(k.Hash); err != nil {
+		return err
+	}
+
+	p := k.Options
+	p.KeyLen = uint32(len(k.Hash))
+
+	return verifyKey(password, &p, k.Salt, k.Hash)
+}
+
+// String returns the key in the form written by HashPassword. Salt and hash
+// are encoded using Options.Encoding and a checksum is appended if
+// Options.Checksum is set. The label and the Trailing segments follow the hash
+// in the order HashPassword writes them. The variant and version of the key
+// are written as they are, so keys returned by Inspect or DecodeBinary keep
+// them. An empty variant is written as argon2id and a zero version as
+// Options.Version.
+func (k Key) String() string {
+	variant := k.Variant
+	if variant == "" {
+		variant = "argon2id"
+	}
+
+	options := k.Options
+	if k.Version != 0 {
+		options.Version = k.Version
+	}
+
+	return encodeVariantKey(variant, &options, k.Salt, k.Hash, k.trailingSegments(&options)...)
+}
+
+// trailingSegments returns the label segment of the given options and the
+// Trailing segments of the key, ordered by their prefix like trailingPrefixes.
+func (k Key) trailingSegments(options *Options) []string {
+	var segments []string
+	for _, prefix := range trailingPrefixes {
+		if prefix == labelPrefix {
+			segments = append(segments, options.labelSegments()...)
+			continue
+		}
+
+		for _, segment := range k.Trailing {
+			if strings.HasPrefix(segment, prefix) {
+				segments = append(segments, segment)
+			}
+		}
+	}
+
+	return segments
+}
+
+// MarshalText implements encoding.TextMarshaler using String, so a Key can be
+// used as a struct field encoded to JSON or similar formats.
+func (k Key) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using DecodeKey.
+func (k *Key) UnmarshalText(text []byte) error {
+	decoded, err := DecodeKey(string(text))
+	if err != nil {
+		return err
+	}
+
+	*k = *decoded
+
+	return nil
+}
+
+// Value implements driver.Valuer, so a Key is stored as its string form.
+func (k Key) Value() (driver.Value, error) {
+	return k.String(), nil
+}
+
+// Scan implements sql.Scanner using DecodeKey. It accepts string and []byte
+// values.
+func (k *Key) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return k.UnmarshalText([]byte(v))
+	case []byte:
+		return k.UnmarshalText(v)
+	}
+
+	return ErrInvalidKeyFormat
+}
+
+// KeyInfo contains everything that can be read from an argon2 key.
+type KeyInfo struct {
+	Key
+
+	// Encoding is the base64 encoding of the salt and hash as detected by
+	// DetectEncoding.
+	Encoding *base64.Encoding
+}
+
+// Inspect parses the given argon2 key and returns everything it contains. In
+// contrast to VerifyPassword it accepts all argon2 variants and versions, so
+// it can be used by tooling to report on stored keys.
+func Inspect(key string) (*KeyInfo, error) {
+	if err := checkVariant(key); err != nil && err != ErrUnsupportedVariant {
+		return nil, err
+	}
+
+	encoding, err := DetectEncoding(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedKey, version, p, err := parseSegments(key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, hash, err := decodeSegments(decodedKey, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	p.KeyLen = uint32(len(hash))
+	p.Checksum = strings.Contains(key, "$"+checksumPrefix)
+	if encoding != base64.RawURLEncoding {
+		p.Encoding = encoding
+	}
+
+	return &KeyInfo{
+		Key: Key{
+			Variant:  decodedKey[1],
+			Version:  version,
+			Options:  *p,
+			Salt:     salt,
+			Hash:     hash,
+			Trailing: unlabeledSegments(trailing),
+		},
+		Encoding: encoding,
+	}, nil
+}
+
+// KeyID returns the key management id of the given argon2 key, which some
+// enterprise encoders append as a trailing "$keyid=" segment. It returns an
+// empty string if the key has no key id. The key id is ignored by
+// VerifyPassword.
+func KeyID(key string) (string, error) {
+	_, trailing, err := splitKeyTrailing(key)
+	if err != nil {
+		return "", err
+	}
+
+	for _, segment := range trailing {
+		if strings.HasPrefix(segment, keyIDPrefix) {
+			return segment[len(keyIDPrefix):], nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,628 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestMaskKey(t *testing.T) {
+	t.Run("ValidKey", func(t *testing.T) {
+		key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+		masked := argon2id.MaskKey(key)
+
+		if masked != "$argon2id$v=19$m=65536,t=1,p=4$***$***" {
+			t.Fatal("Expected pre-defined masked key.")
+		}
+
+		if strings.Contains(masked, "c2FsdA") || strings.Contains(masked, "OWwmnKFe") {
+			t.Fatal("Did not expect salt or hash.")
+		}
+	})
+
+	t.Run("TrailingSegments", func(t *testing.T) {
+		key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU$crc=01234567"
+		if masked := argon2id.MaskKey(key); masked != "$argon2id$v=19$m=65536,t=1,p=4$***$***$***" {
+			t.Fatal("Expected pre-defined masked key.")
+		}
+	})
+
+	t.Run("MalformedKey", func(t *testing.T) {
+		for _, key := range []string{"", "secret", "$argon2id$v=19$c2FsdA"} {
+			if masked := argon2id.MaskKey(key); masked != "***" {
+				t.Fatalf("Expected fully masked key for %q.", key)
+			}
+		}
+	})
+}
+
+func TestSaltLenOf(t *testing.T) {
+	t.Run("ValidKey", func(t *testing.T) {
+		// password:salt
+		key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+		if l, err := argon2id.SaltLenOf(key); err != nil {
+			t.Fatal(err)
+		} else if l != 4 {
+			t.Fatal("Expected salt length of 4.")
+		}
+	})
+
+	t.Run("EmptyKey", func(t *testing.T) {
+		if _, err := argon2id.SaltLenOf(""); err != argon2id.ErrArgon2KeyRequired {
+			t.Fatal("Expected ErrArgon2KeyRequired.")
+		}
+	})
+
+	t.Run("MalformedKey", func(t *testing.T) {
+		for _, key := range []string{"$argon2id$v=19", "$argon2id$v=19$m=65536,t=1,p=4$*$c2FsdA"} {
+			if _, err := argon2id.SaltLenOf(key); err == nil {
+				t.Fatalf("Expected error for %q.", key)
+			}
+		}
+	})
+}
+
+func TestCanonicalizeKey(t *testing.T) {
+	// password:salt
+	canonical := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("Canonical", func(t *testing.T) {
+		if k, err := argon2id.CanonicalizeKey(canonical); err != nil {
+			t.Fatal(err)
+		} else if k != canonical {
+			t.Fatal("Expected key to be unchanged.")
+		}
+	})
+
+	t.Run("ReorderedParameters", func(t *testing.T) {
+		for _, parameters := range []string{"t=1,m=65536,p=4", "p=4,t=1,m=65536", "m=65536,p=4,t=1"} {
+			key := strings.Replace(canonical, "m=65536,t=1,p=4", parameters, 1)
+
+			if err := argon2id.VerifyPassword("password", key); err != nil {
+				t.Fatal(err)
+			}
+
+			if k, err := argon2id.CanonicalizeKey(key); err != nil {
+				t.Fatal(err)
+			} else if k != canonical {
+				t.Fatalf("Expected canonical key for %q.", parameters)
+			}
+		}
+	})
+
+	t.Run("StdEncoding", func(t *testing.T) {
+		// password:salt
+		urlKey := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+		stdKey := "$argon2id$v=0x13$t=1,p=1,m=8$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA"
+
+		if k, err := argon2id.CanonicalizeKey(stdKey); err != nil {
+			t.Fatal(err)
+		} else if k != urlKey {
+			t.Fatal("Expected canonical key.")
+		}
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, parameters := range []string{"m=65536,t=1", "m=65536,t=1,p=4,p=4", "m=65536,t=1,x=4", "m=65536,t=1,p=256", "m=65536,t=1,p", "m=65536,t=1,p=4,"} {
+			key := strings.Replace(canonical, "m=65536,t=1,p=4", parameters, 1)
+
+			if _, err := argon2id.CanonicalizeKey(key); err != argon2id.ErrInvalidParameters {
+				t.Fatalf("Expected ErrInvalidParameters for %q.", parameters)
+			}
+		}
+	})
+}
+
+func TestEncodeKeyRoundTrip(t *testing.T) {
+	// A fixed seed keeps failures reproducible.
+	r := rand.New(rand.NewSource(1))
+
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		r.Read(b)
+		return b
+	}
+
+	encodings := []*base64.Encoding{nil, base64.RawURLEncoding, base64.RawStdEncoding}
+
+	for i := 0; i < 1000; i++ {
+		threads := uint8(1 + r.Intn(255))
+		options := &argon2id.Options{
+			Time:     1 + uint32(r.Int63n(1<<32-1)),
+			Memory:   8*uint32(threads) + uint32(r.Int63n(1<<32-8*int64(threads))),
+			Threads:  threads,
+			KeyLen:   16 + uint32(r.Intn(113)),
+			Checksum: r.Intn(2) == 0,
+			Encoding: encodings[r.Intn(len(encodings))],
+		}
+
+		if err := options.Validate(); err != nil {
+			t.Fatalf("Generated invalid options %v: %v", options, err)
+		}
+
+		salt := randomBytes(1 + r.Intn(64))
+		hash := randomBytes(int(options.KeyLen))
+
+		key := argon2id.EncodeKey(options, salt, hash)
+
+		parsed, parsedSalt, parsedHash, err := argon2id.ParseKey(key)
+		if err != nil {
+			t.Fatalf("Expected %q to parse: %v", key, err)
+		}
+
+		if parsed.Time != options.Time || parsed.Memory != options.Memory ||
+			parsed.Threads != options.Threads || parsed.KeyLen != options.KeyLen {
+			t.Fatalf("Expected options %v, got %v for %q.", options, parsed, key)
+		}
+
+		if !bytes.Equal(parsedSalt, salt) || !bytes.Equal(parsedHash, hash) {
+			t.Fatalf("Expected salt and hash to round trip for %q.", key)
+		}
+
+		info, err := argon2id.Inspect(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Options.Checksum != options.Checksum {
+			t.Fatalf("Expected checksum %v for %q.", options.Checksum, key)
+		}
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("PinnedVector", func(t *testing.T) {
+		k, err := argon2id.DecodeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k.Variant != "argon2id" || k.Version != 19 {
+			t.Fatal("Expected argon2id version 19.")
+		}
+
+		if !reflect.DeepEqual(k.Options, argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32}) {
+			t.Fatal("Expected pinned options.")
+		}
+
+		if !bytes.Equal(k.Salt, []byte("salt")) {
+			t.Fatal("Expected salt to be salt.")
+		}
+
+		if k.String() != key {
+			t.Fatal("Expected String to return the key.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		k, err := argon2id.DecodeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := k.Verify("password"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := k.Verify("wrong"); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if err := k.Verify(""); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("VerifyChecksParameters", func(t *testing.T) {
+		options := &argon2id.Options{Time: 0, Memory: 8, Threads: 0}
+
+		info, err := argon2id.DecodeBinary(argon2id.EncodeBinary(options, []byte("salt"), bytes.Repeat([]byte{0xaa}, 32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := info.Verify("password"); err != argon2id.ErrInvalidParameters {
+			t.Fatal("Expected ErrInvalidParameters.")
+		}
+	})
+
+	t.Run("VerifyChecksHashLength", func(t *testing.T) {
+		info, err := argon2id.Inspect("$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YA")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := info.Verify("password"); err != argon2id.ErrInvalidHashLength {
+			t.Fatal("Expected ErrInvalidHashLength.")
+		}
+	})
+
+	t.Run("VerifyChecksVariant", func(t *testing.T) {
+		info, err := argon2id.Inspect(strings.Replace(key, "argon2id", "argon2d", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := info.Verify("password"); err != argon2id.ErrUnsupportedVariant {
+			t.Fatal("Expected ErrUnsupportedVariant.")
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		withChecksum, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Checksum: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k, err := argon2id.DecodeKey(withChecksum)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !k.Options.Checksum || k.String() != withChecksum {
+			t.Fatal("Expected checksum to be kept.")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := argon2id.DecodeKey("$argon2id$v=19"); !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+
+		if _, err := argon2id.DecodeKey(strings.Replace(key, "argon2id", "argon2i", 1)); err != argon2id.ErrUnsupportedVariant {
+			t.Fatal("Expected ErrUnsupportedVariant.")
+		}
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var k argon2id.Key
+		if err := k.UnmarshalText([]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+
+		text, err := k.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(text) != key {
+			t.Fatal("Expected MarshalText to return the key.")
+		}
+
+		if err := k.UnmarshalText([]byte("invalid")); err == nil {
+			t.Fatal("Expected error.")
+		}
+	})
+}
+
+func TestKeyEncoding(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	type user struct {
+		Name string       `json:"name"`
+		Key  argon2id.Key `json:"key"`
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var u user
+		if err := json.Unmarshal([]byte(`{"name":"user","key":"`+key+`"}`), &u); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := u.Key.Verify("password"); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != `{"name":"user","key":"`+key+`"}` {
+			t.Fatal("Expected key to be encoded as string.")
+		}
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		var u user
+		if err := json.Unmarshal([]byte(`{"key":"$argon2id$v=19"}`), &u); !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+	})
+
+	t.Run("SQL", func(t *testing.T) {
+		for _, src := range []interface{}{key, []byte(key)} {
+			var k argon2id.Key
+			if err := k.Scan(src); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := k.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v != key {
+				t.Fatal("Expected value to be the key.")
+			}
+		}
+
+		var k argon2id.Key
+		if err := k.Scan(42); err != argon2id.ErrInvalidKeyFormat {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		labeled, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Nonce: true, Label: "login", Checksum: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys := map[string]string{
+			"Wrapped":  key + "$layered=legacy",
+			"KeyID":    key + "$keyid=kms-1",
+			"Std":      "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA",
+			"Trailing": labeled,
+		}
+
+		for name, key := range keys {
+			var k argon2id.Key
+			if err := k.Scan(key); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			v, err := k.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v != key {
+				t.Fatalf("%s: Expected %q, got %q.", name, key, v)
+			}
+
+			text, err := json.Marshal(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded argon2id.Key
+			if err := json.Unmarshal(text, &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			if decoded.String() != key {
+				t.Fatalf("%s: Expected JSON to keep the key.", name)
+			}
+		}
+	})
+
+	t.Run("MixedEncoding", func(t *testing.T) {
+		mixed := "$argon2id$v=19$m=8,t=1,p=1$c2Fs-_A$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA"
+
+		if _, err := argon2id.DecodeKey(mixed); err != argon2id.ErrUnknownEncoding {
+			t.Fatalf("Expected ErrUnknownEncoding, got %v.", err)
+		}
+	})
+	t.Run("OtherVariantAndVersion", func(t *testing.T) {
+		other := strings.Replace(strings.Replace(key, "argon2id", "argon2d", 1), "v=19", "v=16", 1)
+
+		info, err := argon2id.Inspect(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := info.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v != other {
+			t.Fatal("Expected variant and version to be kept.")
+		}
+	})
+}
+
+func TestInspect(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("PinnedVector", func(t *testing.T) {
+		info, err := argon2id.Inspect(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Variant != "argon2id" {
+			t.Fatal("Expected variant argon2id.")
+		}
+
+		if info.Version != 19 {
+			t.Fatal("Expected version 19.")
+		}
+
+		if !reflect.DeepEqual(info.Options, argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32}) {
+			t.Fatal("Expected pinned options.")
+		}
+
+		if !bytes.Equal(info.Salt, []byte("salt")) {
+			t.Fatal("Expected salt to be salt.")
+		}
+
+		hash, _ := base64.RawURLEncoding.DecodeString("OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU")
+		if !bytes.Equal(info.Hash, hash) {
+			t.Fatal("Expected pinned hash.")
+		}
+
+		if info.Encoding != base64.RawURLEncoding {
+			t.Fatal("Expected RawURLEncoding.")
+		}
+	})
+
+	t.Run("StdEncoding", func(t *testing.T) {
+		info, err := argon2id.Inspect("$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY/v2Y0dxDBpDeQe1FKQN/bGUq0pAw8IzhL7BMHA")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Encoding != base64.RawStdEncoding {
+			t.Fatal("Expected RawStdEncoding.")
+		}
+	})
+
+	t.Run("OtherVariant", func(t *testing.T) {
+		info, err := argon2id.Inspect(strings.Replace(key, "argon2id", "argon2i", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Variant != "argon2i" {
+			t.Fatal("Expected variant argon2i.")
+		}
+	})
+
+	t.Run("OtherVersion", func(t *testing.T) {
+		info, err := argon2id.Inspect(strings.Replace(key, "v=19", "v=16", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Version != 16 {
+			t.Fatal("Expected version 16.")
+		}
+	})
+
+	t.Run("WrongAlgorithm", func(t *testing.T) {
+		if _, err := argon2id.Inspect("$2a$10$abcdefghijklmnopqrstuv"); err != argon2id.ErrWrongAlgorithm {
+			t.Fatal("Expected ErrWrongAlgorithm.")
+		}
+	})
+}
+
+func TestKeyID(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+	withID := key + "$keyid=kms-2024-01"
+
+	t.Run("WithoutKeyID", func(t *testing.T) {
+		if id, err := argon2id.KeyID(key); err != nil {
+			t.Fatal(err)
+		} else if id != "" {
+			t.Fatal("Expected empty key id.")
+		}
+	})
+
+	t.Run("WithKeyID", func(t *testing.T) {
+		if id, err := argon2id.KeyID(withID); err != nil {
+			t.Fatal(err)
+		} else if id != "kms-2024-01" {
+			t.Fatal("Expected key id kms-2024-01.")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		for _, k := range []string{key, withID} {
+			if err := argon2id.VerifyPassword("password", k); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := argon2id.VerifyPassword("password1", k); err != argon2id.ErrHashNotEqualPassword {
+				t.Fatal("Expected ErrHashNotEqualPassword.")
+			}
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.KeyID("$argon2id$v=19"); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+}
+
+func TestParseKey(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("ValidKey", func(t *testing.T) {
+		options, salt, hash, err := argon2id.ParseKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(*options, argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32}) {
+			t.Fatal("Expected pinned options.")
+		}
+
+		if string(salt) != "salt" {
+			t.Fatal("Expected salt to be salt.")
+		}
+
+		if len(hash) != 32 {
+			t.Fatal("Expected 32 byte hash.")
+		}
+	})
+
+	for _, c := range []struct {
+		name string
+		key  string
+		err  error
+	}{
+		{"SegmentCount", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA", argon2id.ErrInvalidKeyLength},
+		{"Version", "$argon2id$v=x$m=65536,t=1,p=4$c2FsdA$c2FsdA", argon2id.ErrInvalidVersion},
+		{"Parameters", "$argon2id$v=19$m=65536,t=1$c2FsdA$c2FsdA", argon2id.ErrInvalidParameters},
+		{"SaltBase64", "$argon2id$v=19$m=65536,t=1,p=4$c2F*dA$c2FsdA", argon2id.ErrInvalidBase64},
+		{"HashBase64", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$c2F*dA", argon2id.ErrInvalidBase64},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, _, _, err := argon2id.ParseKey(c.key)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+
+			if !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+				t.Fatal("Expected ErrInvalidKeyFormat.")
+			}
+		})
+	}
+}
+
+func TestEncodeKey(t *testing.T) {
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		options, salt, hash, err := argon2id.ParseKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if encoded := argon2id.EncodeKey(options, salt, hash); encoded != key {
+			t.Fatalf("Expected %s, got %s.", key, encoded)
+		}
+	})
+
+	t.Run("MatchesHashPassword", func(t *testing.T) {
+		options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Checksum: true}
+
+		expected, err := argon2id.HashPassword("password", "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, salt, hash, err := argon2id.ParseKey(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if encoded := argon2id.EncodeKey(options, salt, hash); encoded != expected {
+			t.Fatalf("Expected %s, got %s.", expected, encoded)
+		}
+	})
+}
@@ -0,0 +1,417 @@
+package argon2id
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// interactiveBudget is the maximum duration a single hash may take to be
+// considered safe for interactive use.
+const interactiveBudget = 500 * time.Millisecond
+
+// referenceOptions are the options of the hash that is measured once to
+// estimate the duration of other options on the current host.
+var referenceOptions = Options{
+	Time:    1,
+	Memory:  8 * 1024,
+	Threads: 1,
+	KeyLen:  32,
+}
+
+var (
+	referenceOnce     sync.Once
+	referenceDuration time.Duration
+)
+
+// estimateDuration estimates how long a single hash using the given options
+// takes on the current host. It measures the reference options once and scales
+// the result linearly by memory and time. The speedup from multiple threads is
+// ignored, so the estimate errs on the slow side.
+func estimateDuration(o *Options) time.Duration {
+	referenceOnce.Do(func() {
+		for i := 0; i < 3; i++ {
+			start := now()
+			argon2.IDKey(
+				[]byte("password"), []byte("somesalt"),
+				referenceOptions.Time, referenceOptions.Memory,
+				referenceOptions.Threads, referenceOptions.KeyLen,
+			)
+
+			if d := now().Sub(start); i == 0 || d < referenceDuration {
+				referenceDuration = d
+			}
+		}
+	})
+
+	scale := float64(o.Memory) * float64(o.Time) /
+		(float64(referenceOptions.Memory) * float64(referenceOptions.Time))
+
+	return time.Duration(float64(referenceDuration) * scale)
+}
+
+// IsInteractive reports whether a single hash using the options is estimated
+// to complete in under 500ms on the current host. The estimate is based on a
+// small reference hash that is measured once per process, the options
+// themselves are never run. Applications can use it to decide between hashing
+// synchronously and offloading the work. Nil options are treated as
+// DefaultOptions.
+func (o *Options) IsInteractive() bool {
+	return estimateDuration(optionsOrDefault(o)) < interactiveBudget
+}
+
+// MemoryHuman returns the memory of the options in the largest binary unit
+// that keeps the value at or above one, e.g. "64 MiB" for a Memory of 65536.
+// Memory is given in KiB, so KiB is the smallest unit. Values are rounded to
+// two decimals. Nil options are treated as DefaultOptions.
+func (o *Options) MemoryHuman() string {
+	o = optionsOrDefault(o)
+
+	value := float64(o.Memory)
+	unit := "KiB"
+
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < 1024 {
+			break
+		}
+
+		value /= 1024
+		unit = next
+	}
+
+	value = math.Round(value*100) / 100
+
+	return strconv.FormatFloat(value, 'f', -1, 64) + " " + unit
+}
+
+// OptionsFromMap builds options from a generic map as returned by most config
+// libraries. It reads the keys "time", "memory", "threads" and "keyLen", each
+// of which may hold any integer type, a float64 without fraction (as decoded
+// from JSON) or a json.Number. Missing keys are taken from DefaultOptions.
+// Zero, negative, fractional or out of range values return ErrInvalidOptions,
+// as do combinations rejected by Options.Validate.
+func OptionsFromMap(m map[string]interface{}) (*Options, error) {
+	o := *DefaultOptions
+
+	fields := []struct {
+		key string
+		max uint64
+		set func(v uint64)
+	}{
+		{"time", math.MaxUint32, func(v uint64) { o.Time = uint32(v) }},
+		{"memory", math.MaxUint32, func(v uint64) { o.Memory = uint32(v) }},
+		{"threads", math.MaxUint8, func(v uint64) { o.Threads = uint8(v) }},
+		{"keyLen", math.MaxUint32, func(v uint64) { o.KeyLen = uint32(v) }},
+	}
+
+	for _, f := range fields {
+		raw, ok := m[f.key]
+		if !ok {
+			continue
+		}
+
+		v, ok := toUint64(raw)
+		if !ok || v == 0 || v > f.max {
+			return nil, fmt.Errorf("%w Key %q has value %v.", ErrInvalidOptions, f.key, raw)
+		}
+
+		f.set(v)
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// String returns the options in the form of the parameter segment of an
+// argon2 key followed by the key length, e.g. "m=65536,t=1,p=4,keyLen=32". The
+// Secret is never included, also when the options are printed by value.
+func (o Options) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d,keyLen=%d", o.Memory, o.Time, o.Threads, o.KeyLen)
+}
+
+// DefaultOptionsForHost returns a copy of DefaultOptions with Threads lowered
+// to the number of CPUs of the current host, if it has fewer than 4. Keys
+// created with it can be verified on any host, only the speed differs.
+func DefaultOptionsForHost() *Options {
+	o := *DefaultOptions
+
+	if cpus := runtime.NumCPU(); cpus < int(o.Threads) {
+		o.Threads = uint8(cpus)
+	}
+
+	return &o
+}
+
+// ValidateForHost works like Validate but also returns ErrTooManyThreads if
+// Threads exceeds the number of CPUs of the current host, which oversubscribes
+// it, e.g. in containers limited to a single CPU. The error is a warning, the
+// options can still be used.
+func (o *Options) ValidateForHost() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	if int(o.Threads) > runtime.NumCPU() {
+		return ErrTooManyThreads
+	}
+
+	return nil
+}
+
+// Option changes a single field of the options built by NewOptions.
+type Option func(o *Options)
+
+// NewOptions returns a copy of DefaultOptions with the given options applied,
+// e.g. NewOptions(WithMemory(128*1024)). The result is not validated, use
+// Options.Validate to check it.
+func NewOptions(opts ...Option) *Options {
+	o := *DefaultOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &o
+}
+
+// WithTime sets the number of passes over the memory.
+func WithTime(time uint32) Option {
+	return func(o *Options) {
+		o.Time = time
+	}
+}
+
+// WithMemory sets the memory in KiB.
+func WithMemory(memory uint32) Option {
+	return func(o *Options) {
+		o.Memory = memory
+	}
+}
+
+// WithThreads sets the number of threads.
+func WithThreads(threads uint8) Option {
+	return func(o *Options) {
+		o.Threads = threads
+	}
+}
+
+// WithKeyLen sets the length of the hash in bytes.
+func WithKeyLen(keyLen uint32) Option {
+	return func(o *Options) {
+		o.KeyLen = keyLen
+	}
+}
+
+// Equal reports whether Time, Memory, Threads, KeyLen, Version and Label, the
+// parameters stored in a key that change its hash, are equal in both options.
+// Checksum, Nonce, Encoding, Secret and MinSaltLen are not compared. Nil
+// options are treated as DefaultOptions. NeedsRehash uses it to compare the
+// options of a key to the target options.
+func (o *Options) Equal(other *Options) bool {
+	a, b := optionsOrDefault(o), optionsOrDefault(other)
+
+	return a.Time == b.Time &&
+		a.Memory == b.Memory &&
+		a.Threads == b.Threads &&
+		a.KeyLen == b.KeyLen &&
+		a.version() == b.version() &&
+		a.Label == b.Label
+}
+
+// optionsJSON is the JSON form of Options.
+type optionsJSON struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	KeyLen  uint32 `json:"keyLen"`
+}
+
+// MarshalJSON implements json.Marshaler. It writes Time, Memory, Threads and
+// KeyLen as "time", "memory", "threads" and "keyLen". The other fields, in
+// particular Secret, are not written. It has a value receiver, so options
+// held by value, e.g. as a struct field, are written the same way.
+func (o Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(optionsJSON{
+		Time:    o.Time,
+		Memory:  o.Memory,
+		Threads: o.Threads,
+		KeyLen:  o.KeyLen,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It reads the keys written by
+// MarshalJSON like OptionsFromMap, so missing keys are taken from
+// DefaultOptions and invalid options return an error matching
+// ErrInvalidOptions. The other fields of the options are left untouched.
+func (o *Options) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return err
+	}
+
+	parsed, err := OptionsFromMap(m)
+	if err != nil {
+		return err
+	}
+
+	o.Time = parsed.Time
+	o.Memory = parsed.Memory
+	o.Threads = parsed.Threads
+	o.KeyLen = parsed.KeyLen
+
+	return nil
+}
+
+// toUint64 converts the given numeric value to an uint64. It returns false if
+// the value is not numeric, negative or has a fraction.
+func toUint64(raw interface{}) (uint64, bool) {
+	switch v := raw.(type) {
+	case int:
+		return uint64(v), v >= 0
+	case int8:
+		return uint64(v), v >= 0
+	case int16:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float32:
+		return toUint64(float64(v))
+	case float64:
+		if v < 0 || v != math.Trunc(v) || v > math.MaxUint64 {
+			return 0, false
+		}
+
+		return uint64(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return toUint64(i)
+		}
+
+		return 0, false
+	}
+
+	return 0, false
+}
+
+// Names of the standards known to Options.MeetsStandard.
+const (
+	// StandardOWASP2024 is the argon2id recommendation of the OWASP Password
+	// Storage Cheat Sheet: any of m=46 MiB/t=1, m=19 MiB/t=2, m=12 MiB/t=3,
+	// m=9 MiB/t=4 or m=7 MiB/t=5.
+	StandardOWASP2024 = "OWASP2024"
+
+	// StandardRFC9106Low is the second recommended option of RFC 9106:
+	// m=64 MiB, t=3 and a 256-bit tag.
+	StandardRFC9106Low = "RFC9106-low"
+
+	// StandardRFC9106High is the first recommended option of RFC 9106:
+	// m=2 GiB, t=1 and a 256-bit tag.
+	StandardRFC9106High = "RFC9106-high"
+)
+
+// requirement is a combination of minimum memory in KiB and minimum time.
+type requirement struct {
+	memory uint32
+	time   uint32
+}
+
+// standard contains the minimums of a standard. Options meet a standard if
+// they meet the minimum key length and any of the requirements.
+type standard struct {
+	keyLen       uint32
+	requirements []requirement
+}
+
+// standards maps the names of all known standards to their minimums.
+var standards = map[string]standard{
+	StandardOWASP2024: {
+		requirements: []requirement{
+			{memory: 47104, time: 1},
+			{memory: 19456, time: 2},
+			{memory: 12288, time: 3},
+			{memory: 9216, time: 4},
+			{memory: 7168, time: 5},
+		},
+	},
+	StandardRFC9106Low: {
+		keyLen:       32,
+		requirements: []requirement{{memory: 64 * 1024, time: 3}},
+	},
+	StandardRFC9106High: {
+		keyLen:       32,
+		requirements: []requirement{{memory: 2 * 1024 * 1024, time: 1}},
+	},
+}
+
+// MeetsStandard reports whether the options meet or exceed the minimums of the
+// named standard. See the Standard constants for the known names. Nil options
+// are treated as DefaultOptions.
+func (o *Options) MeetsStandard(name string) (bool, error) {
+	o = optionsOrDefault(o)
+
+	s, ok := standards[name]
+	if !ok {
+		return false, ErrUnknownStandard
+	}
+
+	if o.KeyLen < s.keyLen {
+		return false, nil
+	}
+
+	for _, r := range s.requirements {
+		if o.Memory >= r.memory && o.Time >= r.time {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// SecurityWarnings returns human-readable notes on parameters of the options
+// that fall below the OWASP recommendations, e.g. for logging them at startup.
+// It returns nil if there are none. The notes are advisory, the options can
+// still be used. Nil options are treated as DefaultOptions.
+func (o *Options) SecurityWarnings() []string {
+	o = optionsOrDefault(o)
+
+	if ok, _ := o.MeetsStandard(StandardOWASP2024); ok {
+		return nil
+	}
+
+	minimums := []string{}
+	for _, r := range standards[StandardOWASP2024].requirements {
+		m := Options{Memory: r.memory}
+		minimums = append(minimums, fmt.Sprintf("%s with t=%d", m.MemoryHuman(), r.time))
+	}
+
+	return []string{fmt.Sprintf(
+		"argon2id: memory of %s with t=%d is below the OWASP recommendations, use at least one of %s.",
+		o.MemoryHuman(), o.Time, strings.Join(minimums, ", "),
+	)}
+}
@@ -0,0 +1,528 @@
+package argon2id_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestOptionsIsInteractive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping host measurement in short mode.")
+	}
+
+	t.Run("MinimalOptions", func(t *testing.T) {
+		o := &argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 16}
+		if !o.IsInteractive() {
+			t.Fatal("Expected minimal options to be interactive.")
+		}
+	})
+
+	t.Run("ExtremeMemory", func(t *testing.T) {
+		o := &argon2id.Options{Time: 10, Memory: 4 * 1024 * 1024, Threads: 4, KeyLen: 32}
+		if o.IsInteractive() {
+			t.Fatal("Did not expect extreme options to be interactive.")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var o *argon2id.Options
+		if o.IsInteractive() != argon2id.DefaultOptions.IsInteractive() {
+			t.Fatal("Expected nil to be treated as DefaultOptions.")
+		}
+	})
+}
+
+func TestOptionsMemoryHuman(t *testing.T) {
+	for _, c := range []struct {
+		memory uint32
+		human  string
+	}{
+		{0, "0 KiB"},
+		{512, "512 KiB"},
+		{1023, "1023 KiB"},
+		{1024, "1 MiB"},
+		{1536, "1.5 MiB"},
+		{19 * 1024, "19 MiB"},
+		{64 * 1024, "64 MiB"},
+		{1024 * 1024, "1 GiB"},
+		{2560 * 1024, "2.5 GiB"},
+		{4 * 1024 * 1024, "4 GiB"},
+	} {
+		o := &argon2id.Options{Memory: c.memory}
+		if h := o.MemoryHuman(); h != c.human {
+			t.Fatalf("Expected %q for %d, got %q.", c.human, c.memory, h)
+		}
+	}
+
+	var o *argon2id.Options
+	if h := o.MemoryHuman(); h != "64 MiB" {
+		t.Fatalf("Expected 64 MiB for nil options, got %q.", h)
+	}
+}
+
+func TestOptionsFromMap(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		if o, err := argon2id.OptionsFromMap(nil); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(*o, *argon2id.DefaultOptions) {
+			t.Fatal("Expected default options.")
+		}
+	})
+
+	t.Run("Int", func(t *testing.T) {
+		o, err := argon2id.OptionsFromMap(map[string]interface{}{
+			"time":    2,
+			"memory":  int64(128 * 1024),
+			"threads": uint8(2),
+			"keyLen":  int32(64),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(*o, argon2id.Options{Time: 2, Memory: 128 * 1024, Threads: 2, KeyLen: 64, MinSaltLen: 8}) {
+			t.Fatal("Expected provided options.")
+		}
+	})
+
+	t.Run("Float64", func(t *testing.T) {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(`{"time":3,"memory":32768}`), &m); err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := argon2id.OptionsFromMap(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if o.Time != 3 || o.Memory != 32768 {
+			t.Fatal("Expected provided options.")
+		}
+
+		if o.Threads != argon2id.DefaultOptions.Threads || o.KeyLen != argon2id.DefaultOptions.KeyLen {
+			t.Fatal("Expected missing keys to be defaults.")
+		}
+	})
+
+	t.Run("JSONNumber", func(t *testing.T) {
+		if o, err := argon2id.OptionsFromMap(map[string]interface{}{"threads": json.Number("8")}); err != nil {
+			t.Fatal(err)
+		} else if o.Threads != 8 {
+			t.Fatal("Expected provided threads.")
+		}
+	})
+
+	t.Run("InvalidValues", func(t *testing.T) {
+		for _, m := range []map[string]interface{}{
+			{"time": 0},
+			{"time": -1},
+			{"memory": 1.5},
+			{"threads": 256},
+			{"keyLen": int64(1) << 32},
+			{"keyLen": "32"},
+		} {
+			if _, err := argon2id.OptionsFromMap(m); !errors.Is(err, argon2id.ErrInvalidOptions) {
+				t.Fatalf("Expected ErrInvalidOptions for %v.", m)
+			}
+		}
+	})
+}
+
+func TestOptionsMeetsStandard(t *testing.T) {
+	t.Run("UnknownStandard", func(t *testing.T) {
+		if _, err := argon2id.DefaultOptions.MeetsStandard("OWASP2000"); err != argon2id.ErrUnknownStandard {
+			t.Fatal("Expected ErrUnknownStandard.")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var o *argon2id.Options
+		if meets, err := o.MeetsStandard(argon2id.StandardOWASP2024); err != nil || !meets {
+			t.Fatal("Expected nil to be treated as DefaultOptions.")
+		}
+	})
+
+	for _, c := range []struct {
+		standard string
+		options  argon2id.Options
+		meets    bool
+	}{
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 47104, Time: 1, Threads: 1, KeyLen: 32}, true},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 19456, Time: 2, Threads: 1, KeyLen: 32}, true},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 12288, Time: 3, Threads: 1, KeyLen: 32}, true},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 9216, Time: 4, Threads: 1, KeyLen: 32}, true},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 7168, Time: 5, Threads: 1, KeyLen: 32}, true},
+		{argon2id.StandardOWASP2024, *argon2id.DefaultOptions, true},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 47103, Time: 1, Threads: 1, KeyLen: 32}, false},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 19455, Time: 2, Threads: 1, KeyLen: 32}, false},
+		{argon2id.StandardOWASP2024, argon2id.Options{Memory: 7167, Time: 10, Threads: 1, KeyLen: 32}, false},
+		{argon2id.StandardRFC9106Low, argon2id.Options{Memory: 64 * 1024, Time: 3, Threads: 4, KeyLen: 32}, true},
+		{argon2id.StandardRFC9106Low, argon2id.Options{Memory: 64 * 1024, Time: 2, Threads: 4, KeyLen: 32}, false},
+		{argon2id.StandardRFC9106Low, argon2id.Options{Memory: 64*1024 - 1, Time: 3, Threads: 4, KeyLen: 32}, false},
+		{argon2id.StandardRFC9106Low, argon2id.Options{Memory: 64 * 1024, Time: 3, Threads: 4, KeyLen: 16}, false},
+		{argon2id.StandardRFC9106Low, *argon2id.DefaultOptions, false},
+		{argon2id.StandardRFC9106High, argon2id.Options{Memory: 2 * 1024 * 1024, Time: 1, Threads: 4, KeyLen: 32}, true},
+		{argon2id.StandardRFC9106High, argon2id.Options{Memory: 2*1024*1024 - 1, Time: 4, Threads: 4, KeyLen: 32}, false},
+		{argon2id.StandardRFC9106High, argon2id.Options{Memory: 2 * 1024 * 1024, Time: 1, Threads: 4, KeyLen: 31}, false},
+	} {
+		c := c
+
+		if meets, err := c.options.MeetsStandard(c.standard); err != nil {
+			t.Fatal(err)
+		} else if meets != c.meets {
+			t.Fatalf("Expected %v for %s with %+v.", c.meets, c.standard, c.options)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		options argon2id.Options
+		err     error
+	}{
+		{"Default", *argon2id.DefaultOptions, nil},
+		{"Minimum", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 16}, nil},
+		{"MaximumKeyLen", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 128}, nil},
+		{"Time", argon2id.Options{Time: 0, Memory: 8, Threads: 1, KeyLen: 16}, argon2id.ErrInvalidTime},
+		{"Threads", argon2id.Options{Time: 1, Memory: 8, Threads: 0, KeyLen: 16}, argon2id.ErrInvalidThreads},
+		{"Memory", argon2id.Options{Time: 1, Memory: 31, Threads: 4, KeyLen: 16}, argon2id.ErrInvalidMemory},
+		{"ShortKeyLen", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 15}, argon2id.ErrInvalidKeyLen},
+		{"LongKeyLen", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 129}, argon2id.ErrInvalidKeyLen},
+		{"RawEncoding", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 16, Encoding: base64.RawStdEncoding}, nil},
+		{"PaddedEncoding", argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 16, Encoding: base64.StdEncoding}, argon2id.ErrPaddedEncoding},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			err := c.options.Validate()
+			if err != c.err {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+
+			if c.err != nil && !errors.Is(err, argon2id.ErrInvalidOptions) {
+				t.Fatal("Expected ErrInvalidOptions.")
+			}
+		})
+	}
+
+	t.Run("HashPassword", func(t *testing.T) {
+		if _, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 0, KeyLen: 32}); err != argon2id.ErrInvalidThreads {
+			t.Fatal("Expected ErrInvalidThreads.")
+		}
+
+		if _, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 0}); err != argon2id.ErrInvalidKeyLen {
+			t.Fatal("Expected ErrInvalidKeyLen.")
+		}
+
+		if _, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Encoding: base64.StdEncoding}); err != argon2id.ErrPaddedEncoding {
+			t.Fatal("Expected ErrPaddedEncoding.")
+		}
+	})
+
+	t.Run("OptionsFromMap", func(t *testing.T) {
+		if _, err := argon2id.OptionsFromMap(map[string]interface{}{"memory": 8, "threads": 4}); err != argon2id.ErrInvalidMemory {
+			t.Fatal("Expected ErrInvalidMemory.")
+		}
+	})
+}
+
+func TestOptionsJSON(t *testing.T) {
+	t.Run("Marshal", func(t *testing.T) {
+		options := &argon2id.Options{Time: 2, Memory: 1024, Threads: 2, KeyLen: 16, Secret: []byte("secret")}
+
+		data, err := json.Marshal(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != `{"time":2,"memory":1024,"threads":2,"keyLen":16}` {
+			t.Fatalf("Expected pre-defined JSON, got %s.", data)
+		}
+	})
+
+	t.Run("MarshalValue", func(t *testing.T) {
+		config := struct {
+			Argon2 argon2id.Options `json:"argon2"`
+		}{
+			Argon2: argon2id.Options{Time: 2, Memory: 1024, Threads: 2, KeyLen: 16, Secret: []byte("secret")},
+		}
+
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != `{"argon2":{"time":2,"memory":1024,"threads":2,"keyLen":16}}` {
+			t.Fatalf("Expected pre-defined JSON, got %s.", data)
+		}
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		var config struct {
+			Argon2 argon2id.Options `json:"argon2"`
+		}
+
+		if err := json.Unmarshal([]byte(`{"argon2":{"time":2,"memory":1024,"threads":2,"keyLen":16}}`), &config); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(config.Argon2, argon2id.Options{Time: 2, Memory: 1024, Threads: 2, KeyLen: 16}) {
+			t.Fatalf("Expected pre-defined options, got %+v.", config.Argon2)
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		data, err := json.Marshal(argon2id.DefaultOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o argon2id.Options
+		if err := json.Unmarshal(data, &o); err != nil {
+			t.Fatal(err)
+		}
+
+		if !o.Equal(argon2id.DefaultOptions) {
+			t.Fatal("Expected DefaultOptions.")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var o argon2id.Options
+
+		if err := json.Unmarshal([]byte(`{"threads":0}`), &o); !errors.Is(err, argon2id.ErrInvalidOptions) {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+
+		if err := json.Unmarshal([]byte(`{"memory":16,"threads":4}`), &o); err != argon2id.ErrInvalidMemory {
+			t.Fatal("Expected ErrInvalidMemory.")
+		}
+	})
+}
+
+func TestOptionsString(t *testing.T) {
+	if s := argon2id.DefaultOptions.String(); s != "m=65536,t=1,p=4,keyLen=32" {
+		t.Fatalf("Expected m=65536,t=1,p=4,keyLen=32, got %s.", s)
+	}
+
+	options := &argon2id.Options{Time: 3, Memory: 8, Threads: 1, KeyLen: 16, Secret: []byte("secret")}
+	if s := fmt.Sprint(options); s != "m=8,t=3,p=1,keyLen=16" {
+		t.Fatalf("Expected m=8,t=3,p=1,keyLen=16, got %s.", s)
+	}
+
+	if s := fmt.Sprint(*options); s != "m=8,t=3,p=1,keyLen=16" {
+		t.Fatalf("Expected m=8,t=3,p=1,keyLen=16 for a value, got %s.", s)
+	}
+}
+
+func TestDefaultOptionsForHost(t *testing.T) {
+	o := argon2id.DefaultOptionsForHost()
+
+	expected := argon2id.DefaultOptions.Threads
+	if cpus := runtime.NumCPU(); cpus < int(expected) {
+		expected = uint8(cpus)
+	}
+
+	if o.Threads != expected {
+		t.Fatalf("Expected %d threads, got %d.", expected, o.Threads)
+	}
+
+	if o.Time != argon2id.DefaultOptions.Time || o.Memory != argon2id.DefaultOptions.Memory || o.KeyLen != argon2id.DefaultOptions.KeyLen {
+		t.Fatal("Expected the other fields of DefaultOptions.")
+	}
+
+	if err := o.ValidateForHost(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOptionsValidateForHost(t *testing.T) {
+	t.Run("Invalid", func(t *testing.T) {
+		if err := (&argon2id.Options{Time: 0, Memory: 8, Threads: 1, KeyLen: 32}).ValidateForHost(); err != argon2id.ErrInvalidTime {
+			t.Fatal("Expected ErrInvalidTime.")
+		}
+	})
+
+	t.Run("SingleThread", func(t *testing.T) {
+		if err := (&argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}).ValidateForHost(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("TooManyThreads", func(t *testing.T) {
+		if runtime.NumCPU() >= 255 {
+			t.Skip("Host has too many CPUs.")
+		}
+
+		threads := uint8(runtime.NumCPU() + 1)
+		o := &argon2id.Options{Time: 1, Memory: 8 * uint32(threads), Threads: threads, KeyLen: 32}
+
+		if err := o.ValidateForHost(); err != argon2id.ErrTooManyThreads {
+			t.Fatal("Expected ErrTooManyThreads.")
+		}
+
+		if err := o.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestNewOptions(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		o := argon2id.NewOptions()
+		if !reflect.DeepEqual(*o, *argon2id.DefaultOptions) {
+			t.Fatal("Expected DefaultOptions.")
+		}
+
+		if o == argon2id.DefaultOptions {
+			t.Fatal("Expected a copy of DefaultOptions.")
+		}
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		o := argon2id.NewOptions(argon2id.WithMemory(128 * 1024))
+
+		expected := *argon2id.DefaultOptions
+		expected.Memory = 128 * 1024
+
+		if !reflect.DeepEqual(*o, expected) {
+			t.Fatal("Expected DefaultOptions with 128 MiB.")
+		}
+
+		if argon2id.DefaultOptions.Memory != 64*1024 {
+			t.Fatal("Did not expect DefaultOptions to change.")
+		}
+	})
+
+	t.Run("All", func(t *testing.T) {
+		o := argon2id.NewOptions(
+			argon2id.WithTime(3),
+			argon2id.WithMemory(32*1024),
+			argon2id.WithThreads(2),
+			argon2id.WithKeyLen(64),
+		)
+
+		if o.Time != 3 || o.Memory != 32*1024 || o.Threads != 2 || o.KeyLen != 64 {
+			t.Fatal("Expected provided options.")
+		}
+	})
+
+	t.Run("NotValidated", func(t *testing.T) {
+		if err := argon2id.NewOptions(argon2id.WithTime(0)).Validate(); err != argon2id.ErrInvalidTime {
+			t.Fatal("Expected ErrInvalidTime.")
+		}
+	})
+}
+
+func TestOptionsEqual(t *testing.T) {
+	options := argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32}
+
+	t.Run("Equal", func(t *testing.T) {
+		other := options
+		other.Checksum = true
+		other.Nonce = true
+		other.Secret = []byte("secret")
+		other.Version = 19
+
+		if !options.Equal(&other) {
+			t.Fatal("Expected options to be equal.")
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		if !options.Equal(nil) {
+			t.Fatal("Expected nil to equal DefaultOptions.")
+		}
+
+		var nilOptions *argon2id.Options
+		if !nilOptions.Equal(argon2id.DefaultOptions) {
+			t.Fatal("Expected nil to equal DefaultOptions.")
+		}
+	})
+
+	fields := map[string]func(o *argon2id.Options){
+		"Time":    func(o *argon2id.Options) { o.Time++ },
+		"Memory":  func(o *argon2id.Options) { o.Memory++ },
+		"Threads": func(o *argon2id.Options) { o.Threads++ },
+		"KeyLen":  func(o *argon2id.Options) { o.KeyLen++ },
+		"Version": func(o *argon2id.Options) { o.Version = 20 },
+		"Label":   func(o *argon2id.Options) { o.Label = "login" },
+	}
+
+	for name, change := range fields {
+		change := change
+
+		t.Run(name, func(t *testing.T) {
+			other := options
+			change(&other)
+
+			if options.Equal(&other) || other.Equal(&options) {
+				t.Fatal("Did not expect options to be equal.")
+			}
+		})
+	}
+}
+
+func TestOptionsPresets(t *testing.T) {
+	presets := []*argon2id.Options{
+		argon2id.OptionsInteractive,
+		argon2id.OptionsModerate,
+		argon2id.OptionsSensitive,
+	}
+
+	for i, o := range presets {
+		if err := o.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		previous := presets[i-1]
+		if o == previous || o.Memory <= previous.Memory || o.Time <= previous.Time {
+			t.Fatalf("Expected %v to be more expensive than %v.", o, previous)
+		}
+	}
+}
+
+func TestOptionsSecurityWarnings(t *testing.T) {
+	t.Run("Recommended", func(t *testing.T) {
+		for _, o := range []*argon2id.Options{
+			argon2id.DefaultOptions,
+			argon2id.OptionsInteractive,
+			{Time: 2, Memory: 19 * 1024, Threads: 1, KeyLen: 32},
+			nil,
+		} {
+			if warnings := o.SecurityWarnings(); warnings != nil {
+				t.Fatalf("Did not expect warnings, got %v.", warnings)
+			}
+		}
+	})
+
+	t.Run("LowMemory", func(t *testing.T) {
+		warnings := (&argon2id.Options{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLen: 32}).SecurityWarnings()
+		if len(warnings) != 1 {
+			t.Fatal("Expected a warning.")
+		}
+
+		if !strings.Contains(warnings[0], "8 MiB with t=1") || !strings.Contains(warnings[0], "19 MiB with t=2") {
+			t.Fatalf("Expected options and recommendations in %q.", warnings[0])
+		}
+	})
+
+	t.Run("LowTime", func(t *testing.T) {
+		if warnings := (&argon2id.Options{Time: 1, Memory: 19 * 1024, Threads: 1, KeyLen: 32}).SecurityWarnings(); len(warnings) != 1 {
+			t.Fatal("Expected a warning.")
+		}
+	})
+}
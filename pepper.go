@@ -0,0 +1,128 @@
+package argon2id
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// pepperPassword returns HMAC-SHA256(pepper, password), which is used as the
+// argon2 input instead of the password itself.
+func pepperPassword(pepper []byte, password string) string {
+	return string(pepperPasswordBytes(pepper, []byte(password)))
+}
+
+// pepperPasswordBytes works like pepperPassword but takes and returns byte
+// slices.
+func pepperPasswordBytes(pepper []byte, password []byte) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write(password)
+
+	return mac.Sum(nil)
+}
+
+// VerifyPasswordWithPeppers works like VerifyPasswordWithSecret but tries
+// each of the given peppers in order. It returns the index of the pepper that
+// matched, so a key matching a retired pepper can be rehashed with the active
+// one. An empty pepper stands for a key created without a pepper. The key is
+// parsed only once. If no pepper matches it returns -1 and
+// ErrHashNotEqualPassword.
+func VerifyPasswordWithPeppers(password string, key string, peppers [][]byte) (int, error) {
+	if password == "" {
+		return -1, ErrPasswordRequired
+	}
+
+	if len(peppers) == 0 {
+		return -1, ErrSecretRequired
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return -1, err
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return -1, err
+	}
+
+	defer wipe(salt, hash)
+
+	if p.wrapped {
+		return -1, ErrWrappedKey
+	}
+
+	b := []byte(password)
+	defer wipe(b)
+
+	for i, pepper := range peppers {
+		if len(pepper) == 0 {
+			err = verifyKeyBytes(b, p, salt, hash)
+		} else {
+			peppered := pepperPasswordBytes(pepper, b)
+			err = verifyKeyBytes(peppered, p, salt, hash)
+			wipe(peppered)
+		}
+
+		if err == nil {
+			return i, nil
+		}
+	}
+
+	return -1, ErrHashNotEqualPassword
+}
+
+// PepperedHasher hashes passwords after applying HMAC-SHA256 with a secret
+// pepper to them, so the pepper can not be forgotten at a call site. Keys
+// created by it can only be verified by a PepperedVerifier using the same
+// pepper.
+type PepperedHasher struct {
+	pepper  []byte
+	options *Options
+}
+
+// NewPepperedHasher returns a PepperedHasher using the given pepper and
+// options.
+func NewPepperedHasher(pepper []byte, options *Options) (*PepperedHasher, error) {
+	if len(pepper) == 0 {
+		return nil, ErrSecretRequired
+	}
+
+	return &PepperedHasher{
+		pepper:  append([]byte(nil), pepper...),
+		options: optionsOrDefault(options),
+	}, nil
+}
+
+// Hash takes a password and a salt and returns an argon2 key of the peppered
+// password.
+func (h *PepperedHasher) Hash(password string, salt string) (string, error) {
+	if password == "" {
+		return "", ErrPasswordRequired
+	}
+
+	return HashPassword(pepperPassword(h.pepper, password), salt, h.options)
+}
+
+// PepperedVerifier verifies passwords against keys created by a
+// PepperedHasher.
+type PepperedVerifier struct {
+	pepper []byte
+}
+
+// NewPepperedVerifier returns a PepperedVerifier using the given pepper.
+func NewPepperedVerifier(pepper []byte) (*PepperedVerifier, error) {
+	if len(pepper) == 0 {
+		return nil, ErrSecretRequired
+	}
+
+	return &PepperedVerifier{pepper: append([]byte(nil), pepper...)}, nil
+}
+
+// Verify takes a password and an argon2 key and compares both after applying
+// the pepper to the password. It will return an error if they are not equal.
+func (v *PepperedVerifier) Verify(password string, key string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	return VerifyPassword(pepperPassword(v.pepper, password), key)
+}
@@ -0,0 +1,134 @@
+package argon2id_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestPepperedHasher(t *testing.T) {
+	t.Run("EmptyPepper", func(t *testing.T) {
+		if _, err := argon2id.NewPepperedHasher(nil, argon2id.DefaultOptions); err != argon2id.ErrSecretRequired {
+			t.Fatal("Expected ErrSecretRequired.")
+		}
+
+		if _, err := argon2id.NewPepperedVerifier([]byte{}); err != argon2id.ErrSecretRequired {
+			t.Fatal("Expected ErrSecretRequired.")
+		}
+	})
+
+	h, err := argon2id.NewPepperedHasher([]byte("pepper"), shortSaltOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := h.Hash("", "salt"); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	key, err := h.Hash("password", "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("CorrectPepper", func(t *testing.T) {
+		v, err := argon2id.NewPepperedVerifier([]byte("pepper"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Verify("password", key); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Verify("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongPepper", func(t *testing.T) {
+		v, err := argon2id.NewPepperedVerifier([]byte("pepper1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Verify("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("AbsentPepper", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestVerifyPasswordWithPeppers(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32, Secret: []byte("old")}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := argon2id.HashPassword("password", "salt", &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peppers := [][]byte{[]byte("new"), []byte("old"), nil}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyPasswordWithPeppers("", key, peppers); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("NoPeppers", func(t *testing.T) {
+		if _, err := argon2id.VerifyPasswordWithPeppers("password", key, nil); err != argon2id.ErrSecretRequired {
+			t.Fatal("Expected ErrSecretRequired.")
+		}
+	})
+
+	t.Run("RetiredPepper", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordWithPeppers("password", key, peppers); err != nil {
+			t.Fatal(err)
+		} else if i != 1 {
+			t.Fatal("Expected index 1.")
+		}
+	})
+
+	t.Run("ActivePepper", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordWithPeppers("password", key, peppers[1:]); err != nil {
+			t.Fatal(err)
+		} else if i != 0 {
+			t.Fatal("Expected index 0.")
+		}
+	})
+
+	t.Run("NoPepper", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordWithPeppers("password", plain, peppers); err != nil {
+			t.Fatal(err)
+		} else if i != 2 {
+			t.Fatal("Expected index 2.")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if i, err := argon2id.VerifyPasswordWithPeppers("wrong", key, peppers); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		} else if i != -1 {
+			t.Fatal("Expected index -1.")
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.VerifyPasswordWithPeppers("password", "$argon2id$v=19", peppers); !errors.Is(err, argon2id.ErrInvalidKeyFormat) {
+			t.Fatal("Expected ErrInvalidKeyFormat.")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package argon2id
+
+import (
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ProfileResult contains the time VerifyPasswordProfiled spent in each phase
+// of the verification.
+type ProfileResult struct {
+	// Parse is the time spent splitting the key and parsing its version and
+	// parameters.
+	Parse time.Duration
+
+	// Decode is the time spent decoding the base64 salt and hash.
+	Decode time.Duration
+
+	// KDF is the time spent deriving the key using argon2.
+	KDF time.Duration
+}
+
+// VerifyPasswordProfiled works like VerifyPassword but also reports how long
+// each phase of the verification took. Phases that were not reached because of
+// an earlier error are reported as zero.
+func VerifyPasswordProfiled(password string, key string) (ProfileResult, error) {
+	result := ProfileResult{}
+
+	if password == "" {
+		return result, ErrPasswordRequired
+	}
+
+	start := now()
+
+	if err := checkVariant(key); err != nil {
+		result.Parse = now().Sub(start)
+		return result, err
+	}
+
+	decodedKey, version, p, err := parseSegments(key)
+	parsed := now()
+	result.Parse = parsed.Sub(start)
+
+	if err != nil {
+		return result, err
+	}
+
+	if version != argon2.Version {
+		return result, &VersionError{Version: version}
+	}
+
+	salt, hash, err := decodeSegments(decodedKey, nil)
+	decoded := now()
+	result.Decode = decoded.Sub(parsed)
+
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkHashLength(hash); err != nil {
+		return result, err
+	}
+
+	p.KeyLen = uint32(len(hash))
+
+	err = verifyKey(password, p, salt, hash)
+	result.KDF = now().Sub(decoded)
+
+	return result, err
+}
@@ -0,0 +1,51 @@
+package argon2id_test
+
+import (
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestVerifyPasswordProfiled(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyPasswordProfiled("", key); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if r, err := argon2id.VerifyPasswordProfiled("password", "$argon2id$v=19"); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		} else if r.Decode != 0 || r.KDF != 0 {
+			t.Fatal("Did not expect decode or kdf time.")
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyPasswordProfiled("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("OneByteHash", func(t *testing.T) {
+		if r, err := argon2id.VerifyPasswordProfiled("password", "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YA"); err != argon2id.ErrInvalidHashLength {
+			t.Fatal("Expected ErrInvalidHashLength.")
+		} else if r.KDF != 0 {
+			t.Fatal("Did not expect kdf time.")
+		}
+	})
+
+	t.Run("KDFDominates", func(t *testing.T) {
+		r, err := argon2id.VerifyPasswordProfiled("password", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.KDF <= r.Parse+r.Decode {
+			t.Fatalf("Expected kdf to dominate, got %+v.", r)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package argon2id
+
+import (
+	"io"
+)
+
+// HashReader works like HashPassword but reads the password from r until EOF,
+// so large inputs such as file contents can be hashed without converting them
+// to a string first. argon2 needs the complete input at once, so the whole
+// input is still held in memory while hashing. It is wiped afterwards. The
+// input is not limited by MaxPasswordLength, keys of longer inputs can only be
+// verified with the limit disabled. Errors while reading are returned as they
+// are.
+func HashReader(r io.Reader, salt string, options *Options) (string, error) {
+	b, err := io.ReadAll(r)
+	defer wipe(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	// MaxPasswordLength does not apply, so call deriveKeyBytes directly.
+	_, key, err := deriveKeyBytes(b, []byte(salt), options)
+
+	return key, err
+}
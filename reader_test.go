@@ -0,0 +1,46 @@
+package argon2id_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestHashReader(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	t.Run("MatchesHashPassword", func(t *testing.T) {
+		input := strings.Repeat("large input ", 100000)
+
+		key, err := argon2id.HashReader(iotest.HalfReader(strings.NewReader(input)), "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func(max int) { argon2id.MaxPasswordLength = max }(argon2id.MaxPasswordLength)
+		argon2id.MaxPasswordLength = 0
+
+		if err := argon2id.VerifyPassword(input, key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("EmptyInput", func(t *testing.T) {
+		if _, err := argon2id.HashReader(strings.NewReader(""), "salt", options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("ReadError", func(t *testing.T) {
+		readErr := errors.New("read failed")
+		r := io.MultiReader(strings.NewReader("password"), iotest.ErrReader(readErr))
+
+		if _, err := argon2id.HashReader(r, "salt", options); err != readErr {
+			t.Fatal("Expected read error.")
+		}
+	})
+}
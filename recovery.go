@@ -0,0 +1,43 @@
+package argon2id
+
+// recoveryCodeLength is the number of random bytes in a recovery code. 15
+// bytes encode to a 20 character code.
+const recoveryCodeLength = 15
+
+// HashWithRecoveryCode hashes the password and a newly generated recovery
+// code, each with its own random salt. It returns the argon2 key of the
+// password, the recovery code and the argon2 key of the recovery code. The
+// recovery code should be shown to the user once and must not be stored, it
+// can be checked later on using VerifyPassword and the recovery key.
+func HashWithRecoveryCode(password string, options *Options) (key string, recoveryCode string, recoveryKey string, err error) {
+	if password == "" {
+		return "", "", "", ErrPasswordRequired
+	}
+
+	salt, err := generateSalt(SaltLength)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key, err = HashPassword(password, salt, options)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	recoveryCode, err = generateSalt(recoveryCodeLength)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	recoverySalt, err := generateSalt(SaltLength)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	recoveryKey, err = HashPassword(recoveryCode, recoverySalt, options)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return key, recoveryCode, recoveryKey, nil
+}
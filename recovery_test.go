@@ -0,0 +1,53 @@
+package argon2id_test
+
+import (
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestHashWithRecoveryCode(t *testing.T) {
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, _, err := argon2id.HashWithRecoveryCode("", argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	key, code, recoveryKey, err := argon2id.HashWithRecoveryCode("password", argon2id.DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("PasswordKey", func(t *testing.T) {
+		if err := argon2id.VerifyPassword("password", key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("RecoveryKey", func(t *testing.T) {
+		if err := argon2id.VerifyPassword(code, recoveryKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("CrossVerification", func(t *testing.T) {
+		if err := argon2id.VerifyPassword(code, key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if err := argon2id.VerifyPassword("password", recoveryKey); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("UniqueCodes", func(t *testing.T) {
+		_, other, _, err := argon2id.HashWithRecoveryCode("password", argon2id.DefaultOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code == other {
+			t.Fatal("Did not expect equal recovery codes.")
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package argon2id
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// needsRehash reports whether a key created with the stored options should be
+// recreated using the target options.
+func needsRehash(stored *Options, target *Options) bool {
+	return !stored.Equal(target)
+}
+
+// NeedsRehash reports whether the given argon2 key should be recreated using
+// the given options, because any of Time, Memory, Threads, KeyLen or Label
+// differ or because the key was created with another argon2 version than
+// Options.Version. It returns an error if the key can not be parsed. It is
+// meant to be called after a successful VerifyPassword.
+func NeedsRehash(key string, options *Options) (bool, error) {
+	if err := checkVariant(key); err != nil {
+		return false, err
+	}
+
+	decodedKey, version, p, err := parseSegments(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, hash, err := decodeSegments(decodedKey, nil)
+	if err != nil {
+		return false, err
+	}
+
+	p.KeyLen = uint32(len(hash))
+	p.Version = version
+
+	return needsRehash(p, options), nil
+}
+
+// verifyWithSecret verifies the password against a parsed key, peppered with
+// the given secret like VerifyPasswordWithSecret does. If the key does not
+// match and a secret is set, the password is verified again without it, so
+// keys created before the secret was introduced are still accepted.
+// unpeppered reports whether the key only matched without the secret.
+func verifyWithSecret(password []byte, p *Options, salt []byte, hash []byte, secret []byte) (unpeppered bool, err error) {
+	if len(secret) == 0 {
+		return false, verifyKeyBytes(password, p, salt, hash)
+	}
+
+	peppered := pepperPasswordBytes(secret, password)
+	defer wipe(peppered)
+
+	err = verifyKeyBytes(peppered, p, salt, hash)
+	if err != ErrHashNotEqualPassword {
+		return false, err
+	}
+
+	if verifyKeyBytes(password, p, salt, hash) != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// VerifyOrRehash takes a password and an argon2 key and compares both. It
+// returns nil if they are equal and the key was created with the target
+// options. If they are equal but the key was created with different options
+// it returns ErrNeedsRehash, the password is still valid in that case and the
+// caller should store a new key created with the target options.
+//
+// If target has a Secret, the password is verified with it like
+// VerifyPasswordWithSecret does. Keys created without the secret are still
+// accepted and return ErrNeedsRehash, so adding a pepper upgrades existing
+// keys on their next login. A wrong password then costs two verifications.
+func VerifyOrRehash(password string, key string, target *Options) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	target = optionsOrDefault(target)
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	buf, b := copyToBuffer(password)
+	defer releaseBuffer(buf, b)
+
+	unpeppered, err := verifyWithSecret(b, p, salt, hash, target.Secret)
+	if err != nil {
+		return err
+	}
+
+	if unpeppered || needsRehash(p, target) {
+		return ErrNeedsRehash
+	}
+
+	return nil
+}
+
+// VerifyAndUpgradeBytes takes a password and an argon2 key and compares both.
+// If they are equal but the key was created with options other than target,
+// it returns a new key for the password created with the target options and a
+// new random salt, and upgraded is true. The decoded salt and hash of the old
+// key and every derived hash are zeroed before returning. The password itself
+// is left untouched and should be wiped by the caller.
+//
+// err is only set if the password could not be verified, so callers can deny
+// access based on it alone. Generating the new salt is retried SaltRetries
+// times. If the new key still can not be created, rehashErr wraps
+// ErrRehashFailed and err is nil: the password is valid and the old key can
+// be kept.
+//
+// If target has a Secret, the old key is verified like VerifyOrRehash does and
+// the new key is created with the secret like HashPassword does. Keys created
+// without the secret are upgraded, so adding a pepper does not lock out
+// existing users. Nil options are treated as DefaultOptions, the error of
+// Options.Validate is returned as err before the password is verified if the
+// target options can not be used with argon2.
+func VerifyAndUpgradeBytes(password []byte, key string, target *Options) (newKey string, upgraded bool, rehashErr error, err error) {
+	if len(password) == 0 {
+		return "", false, nil, ErrPasswordRequired
+	}
+
+	if err := checkPasswordLength(len(password)); err != nil {
+		return "", false, nil, err
+	}
+
+	target = optionsOrDefault(target)
+	if err := target.Validate(); err != nil {
+		return "", false, nil, err
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	defer wipe(salt, hash)
+
+	unpeppered, err := verifyWithSecret(password, p, salt, hash, target.Secret)
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	if !unpeppered && !needsRehash(p, target) {
+		return "", false, nil, nil
+	}
+
+	newSalt, err := generateSaltWithRetry(SaltLength)
+	if err != nil {
+		return "", false, fmt.Errorf("%w %v", ErrRehashFailed, err), nil
+	}
+
+	trailing, err := newTrailingSegments(target)
+	if err != nil {
+		return "", false, fmt.Errorf("%w %v", ErrRehashFailed, err), nil
+	}
+
+	if len(target.Secret) > 0 {
+		password = pepperPasswordBytes(target.Secret, password)
+		defer wipe(password)
+	}
+
+	if target.Label != "" {
+		password = contextPasswordBytes(target.Label, password)
+		defer wipe(password)
+	}
+
+	newHash := argon2.IDKey(
+		password, []byte(newSalt),
+		target.Time, target.Memory, target.Threads, target.KeyLen,
+	)
+
+	defer wipe(newHash)
+
+	return encodeKey(target, []byte(newSalt), newHash, trailing...), true, nil, nil
+}
+
+// VerifyAndRehash takes a password and an argon2 key and compares both. If
+// they are equal but the key was created with options other than the given
+// ones, it returns a new key for the password created with the given options
+// and a new random salt. If the key is up to date the returned key is empty.
+// err is only set if the password could not be verified. If the new key can
+// not be created the returned key is empty as well, the old key stays valid
+// and is upgraded on a later call. Use VerifyAndUpgradeBytes to observe rehash
+// failures.
+func VerifyAndRehash(password string, key string, options *Options) (newKey string, err error) {
+	b := []byte(password)
+	defer wipe(b)
+
+	newKey, _, _, err = VerifyAndUpgradeBytes(b, key, options)
+
+	return newKey, err
+}
@@ -0,0 +1,354 @@
+package argon2id_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	for _, c := range []struct {
+		name    string
+		key     string
+		options argon2id.Options
+		rehash  bool
+	}{
+		{"UpToDate", key, *argon2id.DefaultOptions, false},
+		{"Time", key, argon2id.Options{Time: 2, Memory: 65536, Threads: 4, KeyLen: 32}, true},
+		{"Memory", key, argon2id.Options{Time: 1, Memory: 131072, Threads: 4, KeyLen: 32}, true},
+		{"Threads", key, argon2id.Options{Time: 1, Memory: 65536, Threads: 2, KeyLen: 32}, true},
+		{"KeyLen", key, argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 64}, true},
+		{"Version", strings.Replace(key, "v=19", "v=16", 1), *argon2id.DefaultOptions, true},
+		{"Label", key, argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32, Label: "login"}, true},
+		{"LabelUpToDate", key + "$label=bG9naW4", argon2id.Options{Time: 1, Memory: 65536, Threads: 4, KeyLen: 32, Label: "login"}, false},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			rehash, err := argon2id.NeedsRehash(c.key, &c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rehash != c.rehash {
+				t.Fatalf("Expected %v.", c.rehash)
+			}
+		})
+	}
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if _, err := argon2id.NeedsRehash("$argon2id$v=19", argon2id.DefaultOptions); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+}
+
+func TestVerifyOrRehash(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if err := argon2id.VerifyOrRehash("", key, argon2id.DefaultOptions); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		if err := argon2id.VerifyOrRehash("password", "$argon2id$v=19", argon2id.DefaultOptions); err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		if err := argon2id.VerifyOrRehash("password", key, argon2id.DefaultOptions); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		target := *argon2id.DefaultOptions
+		target.Time = 2
+
+		if err := argon2id.VerifyOrRehash("password", key, &target); !errors.Is(err, argon2id.ErrNeedsRehash) {
+			t.Fatal("Expected ErrNeedsRehash.")
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		target := *argon2id.DefaultOptions
+		target.Time = 2
+
+		if err := argon2id.VerifyOrRehash("password1", key, &target); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		target := *argon2id.DefaultOptions
+		target.Secret = []byte("pepper")
+
+		options := *shortSaltOptions
+		options.Secret = target.Secret
+
+		peppered, err := argon2id.HashPassword("password", "salt", &options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyOrRehash("password", peppered, &target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyOrRehash("password", key, &target); !errors.Is(err, argon2id.ErrNeedsRehash) {
+			t.Fatal("Expected ErrNeedsRehash for a key created without the secret.")
+		}
+
+		if err := argon2id.VerifyOrRehash("password1", key, &target); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestVerifyAndUpgradeBytes(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	target := &argon2id.Options{Time: 2, Memory: 8 * 1024, Threads: 1, KeyLen: 32}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, _, err := argon2id.VerifyAndUpgradeBytes(nil, key, target); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if _, _, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password1"), key, target); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("UpToDate", func(t *testing.T) {
+		if newKey, upgraded, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, argon2id.DefaultOptions); err != nil {
+			t.Fatal(err)
+		} else if upgraded || newKey != "" {
+			t.Fatal("Did not expect upgrade.")
+		}
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		newKey, upgraded, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !upgraded {
+			t.Fatal("Expected upgrade.")
+		}
+
+		if err := argon2id.VerifyOrRehash("password", newKey, target); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		secret := []byte("pepper")
+		options := *shortSaltOptions
+		options.Secret = secret
+
+		peppered, err := argon2id.HashPassword("password", "salt", &options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		withSecret := *target
+		withSecret.Secret = secret
+
+		newKey, upgraded, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), peppered, &withSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !upgraded {
+			t.Fatal("Expected upgrade.")
+		}
+
+		if err := argon2id.VerifyPasswordWithSecret("password", newKey, secret); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyPassword("password", newKey); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("AddSecret", func(t *testing.T) {
+		secret := []byte("pepper")
+
+		withSecret := *argon2id.DefaultOptions
+		withSecret.Secret = secret
+
+		newKey, upgraded, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, &withSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !upgraded {
+			t.Fatal("Expected upgrade of a key created without the secret.")
+		}
+
+		if err := argon2id.VerifyPasswordWithSecret("password", newKey, secret); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password1"), key, &withSecret); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WipesBuffers", func(t *testing.T) {
+		var wiped [][]byte
+		restore := argon2id.SetWipeHook(func(b []byte) {
+			wiped = append(wiped, b)
+		})
+		defer restore()
+
+		if _, _, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, target); err != nil {
+			t.Fatal(err)
+		}
+
+		// old salt, old hash, control and new hash
+		if len(wiped) != 4 {
+			t.Fatalf("Expected 4 wiped buffers, got %d.", len(wiped))
+		}
+
+		for _, b := range wiped {
+			if len(b) == 0 {
+				t.Fatal("Expected non-empty buffer.")
+			}
+
+			for _, c := range b {
+				if c != 0 {
+					t.Fatal("Expected buffer to be zeroed.")
+				}
+			}
+		}
+	})
+
+	t.Run("TransientRandFailure", func(t *testing.T) {
+		restore := argon2id.SetRandReader(&failingReader{failures: 1, r: rand.Reader})
+		defer restore()
+
+		newKey, upgraded, _, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !upgraded {
+			t.Fatal("Expected upgrade.")
+		}
+
+		if err := argon2id.VerifyOrRehash("password", newKey, target); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("PersistentRandFailure", func(t *testing.T) {
+		restore := argon2id.SetRandReader(&failingReader{failures: argon2id.SaltRetries + 1, r: rand.Reader})
+		defer restore()
+
+		newKey, upgraded, rehashErr, err := argon2id.VerifyAndUpgradeBytes([]byte("password"), key, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !errors.Is(rehashErr, argon2id.ErrRehashFailed) {
+			t.Fatal("Expected ErrRehashFailed.")
+		}
+
+		if upgraded || newKey != "" {
+			t.Fatal("Did not expect upgrade.")
+		}
+	})
+}
+
+// failingReader fails the first failures reads and then reads from r.
+type failingReader struct {
+	failures int
+	r        io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		return 0, errors.New("rand failure")
+	}
+
+	return f.r.Read(p)
+}
+
+func TestVerifyAndRehash(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	target := &argon2id.Options{Time: 2, Memory: 8 * 1024, Threads: 1, KeyLen: 32}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyAndRehash("", key, target); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if _, err := argon2id.VerifyAndRehash("password1", key, target); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("PersistentRandFailure", func(t *testing.T) {
+		restore := argon2id.SetRandReader(&failingReader{failures: argon2id.SaltRetries + 1, r: rand.Reader})
+		defer restore()
+
+		if newKey, err := argon2id.VerifyAndRehash("password", key, target); err != nil {
+			t.Fatal(err)
+		} else if newKey != "" {
+			t.Fatal("Expected empty key.")
+		}
+	})
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		if _, err := argon2id.VerifyAndRehash("password", key, &argon2id.Options{}); !errors.Is(err, argon2id.ErrInvalidOptions) {
+			t.Fatal("Expected ErrInvalidOptions.")
+		}
+	})
+
+	t.Run("UpToDate", func(t *testing.T) {
+		if newKey, err := argon2id.VerifyAndRehash("password", key, argon2id.DefaultOptions); err != nil {
+			t.Fatal(err)
+		} else if newKey != "" {
+			t.Fatal("Expected empty key.")
+		}
+	})
+
+	t.Run("Rehash", func(t *testing.T) {
+		newKey, err := argon2id.VerifyAndRehash("password", key, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rehash, err := argon2id.NeedsRehash(newKey, target); err != nil {
+			t.Fatal(err)
+		} else if rehash {
+			t.Fatal("Expected key using the target options.")
+		}
+
+		if err := argon2id.VerifyPassword("password", newKey); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
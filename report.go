@@ -0,0 +1,59 @@
+package argon2id
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteParamsCSV writes a CSV report of the parameters of the given argon2
+// keys to w. It writes a header followed by one row per key, in the order of
+// the keys, with the columns id, variant, version, memory, time, threads,
+// keyLen, saltLen and error. The id is the index of the key in keys, so rows
+// can be matched to the keys they report on. Keys that can not be parsed do
+// not abort the report, their row only contains the id and the parsing error.
+// Keys of any version are reported.
+func WriteParamsCSV(w io.Writer, keys []string) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{
+		"id", "variant", "version", "memory", "time", "threads", "keyLen", "saltLen", "error",
+	}); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if err := cw.Write(paramsRecord(i, key)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// paramsRecord returns the CSV record of the argon2 key with the given id.
+func paramsRecord(id int, key string) []string {
+	decodedKey, version, p, err := parseSegments(key)
+	if err != nil {
+		return []string{strconv.Itoa(id), "", "", "", "", "", "", "", err.Error()}
+	}
+
+	salt, hash, err := decodeSegments(decodedKey, nil)
+	if err != nil {
+		return []string{strconv.Itoa(id), "", "", "", "", "", "", "", err.Error()}
+	}
+
+	return []string{
+		strconv.Itoa(id),
+		decodedKey[1],
+		strconv.Itoa(version),
+		strconv.FormatUint(uint64(p.Memory), 10),
+		strconv.FormatUint(uint64(p.Time), 10),
+		strconv.FormatUint(uint64(p.Threads), 10),
+		strconv.Itoa(len(hash)),
+		strconv.Itoa(len(salt)),
+		"",
+	}
+}
@@ -0,0 +1,45 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteParamsCSV(t *testing.T) {
+	keys := []string{
+		"$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU",
+		"$argon2id$v=16$t=3,m=8,p=1$c2FsdHNhbHQ$c2FsdHNhbHRzYWx0c2FsdA",
+		"$argon2id$v=19",
+	}
+
+	t.Run("Report", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := argon2id.WriteParamsCSV(&buf, keys); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "id,variant,version,memory,time,threads,keyLen,saltLen,error\n" +
+			"0,argon2id,19,65536,1,4,32,4,\n" +
+			"1,argon2id,16,8,3,1,16,8,\n" +
+			"2,,,,,,,,argon2id: argon2 key invalid length.\n"
+
+		if buf.String() != expected {
+			t.Fatalf("Expected pre-defined report, got %q.", buf.String())
+		}
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		if err := argon2id.WriteParamsCSV(failingWriter{}, keys); err == nil {
+			t.Fatal("Expected error.")
+		}
+	})
+}
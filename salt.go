@@ -0,0 +1,137 @@
+package argon2id
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+)
+
+// SaltLength is the length in bytes of the salts generated by this package. It
+// defaults to 16 bytes as recommended by the argon2 specification.
+var SaltLength = 16
+
+// SaltRetries is the number of times generating a salt is retried if reading
+// from crypto/rand fails while upgrading a key, so a transient failure does not
+// break logins.
+var SaltRetries = 2
+
+// randReader is the source of random bytes for salts. It is only meant to be
+// overridden by tests.
+var randReader io.Reader = rand.Reader
+
+// generateSalt reads length bytes from crypto/rand and returns them encoded
+// using EncodeToBase64String.
+func generateSalt(length int) (string, error) {
+	return generateSaltFrom(randReader, length)
+}
+
+// generateSaltFrom works like generateSalt but reads from r.
+func generateSaltFrom(r io.Reader, length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	return EncodeToBase64String(b), nil
+}
+
+// generateSaltWithRetry works like generateSalt but retries up to SaltRetries
+// times if reading random bytes fails. It returns the last error if every
+// attempt failed.
+func generateSaltWithRetry(length int) (string, error) {
+	salt, err := generateSalt(length)
+	for i := 0; err != nil && i < SaltRetries; i++ {
+		salt, err = generateSalt(length)
+	}
+
+	return salt, err
+}
+
+// GenerateSalt returns length random bytes read from crypto/rand, encoded
+// using EncodeToBase64String so they can be decoded using DecodeBase64String.
+// It returns an error if crypto/rand fails and never falls back to a weaker
+// source.
+func GenerateSalt(length int) (string, error) {
+	if length <= 0 {
+		return "", ErrInvalidSaltLength
+	}
+
+	return generateSalt(length)
+}
+
+// GenerateSaltFrom works like GenerateSalt but reads the random bytes from r,
+// so tests can use a deterministic source to get reproducible salts and keys.
+// A nil r reads from crypto/rand.
+func GenerateSaltFrom(r io.Reader, length int) (string, error) {
+	if length <= 0 {
+		return "", ErrInvalidSaltLength
+	}
+
+	if r == nil {
+		r = rand.Reader
+	}
+
+	return generateSaltFrom(r, length)
+}
+
+// HashPasswordWithSalt takes a password and options and returns an argon2 key
+// using a salt generated by GenerateSalt with a length of SaltLength bytes.
+func HashPasswordWithSalt(password string, options *Options) (string, error) {
+	return HashPasswordWithRandomSalt(password, options)
+}
+
+// HashPasswordWithRandomSalt takes a password and options and returns an
+// argon2 key using a salt of SaltLength random bytes.
+func HashPasswordWithRandomSalt(password string, options *Options) (string, error) {
+	key, _, err := HashPasswordGenerateSalt(password, options)
+	return key, err
+}
+
+// HashPasswordGenerateSalt works like HashPasswordWithRandomSalt but also
+// returns the generated salt, encoded using EncodeToBase64String, for schemas
+// storing the salt separately from the key.
+func HashPasswordGenerateSalt(password string, options *Options) (key string, salt string, err error) {
+	if password == "" {
+		return "", "", ErrPasswordRequired
+	}
+
+	salt, err = GenerateSalt(SaltLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err = HashPassword(password, salt, options)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, salt, nil
+}
+
+// VerifyPasswordHexSalt takes a password, an argon2 key and a hex encoded salt
+// and compares password and key using the given salt. It is meant for keys
+// like "$argon2id$v=19$m=65536,t=1,p=4$$hash" whose salt was stored
+// separately in hex. The salt segment of the key is ignored.
+func VerifyPasswordHexSalt(password string, key string, hexSalt string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	if hexSalt == "" {
+		return ErrSaltRequired
+	}
+
+	salt, err := hex.DecodeString(hexSalt)
+	if err != nil {
+		return ErrInvalidHexSalt
+	}
+
+	p, _, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	return verifyKey(password, p, salt, hash)
+}
@@ -0,0 +1,253 @@
+package argon2id_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestGenerateSalt(t *testing.T) {
+	t.Run("InvalidLength", func(t *testing.T) {
+		for _, length := range []int{0, -1} {
+			if _, err := argon2id.GenerateSalt(length); err != argon2id.ErrInvalidSaltLength {
+				t.Fatal("Expected ErrInvalidSaltLength.")
+			}
+		}
+	})
+
+	t.Run("Length", func(t *testing.T) {
+		salt, err := argon2id.GenerateSalt(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := argon2id.DecodeBase64String(salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(b) != 16 {
+			t.Fatal("Expected 16 bytes.")
+		}
+	})
+
+	t.Run("Distinct", func(t *testing.T) {
+		a, _ := argon2id.GenerateSalt(16)
+		b, _ := argon2id.GenerateSalt(16)
+
+		if a == b {
+			t.Fatal("Expected distinct salts.")
+		}
+	})
+
+	t.Run("RandFailure", func(t *testing.T) {
+		restore := argon2id.SetRandReader(&failingReader{failures: 1})
+		defer restore()
+
+		if _, err := argon2id.GenerateSalt(16); err == nil || errors.Is(err, argon2id.ErrInvalidSaltLength) {
+			t.Fatal("Expected rand error.")
+		}
+	})
+}
+
+func TestGenerateSaltFrom(t *testing.T) {
+	seed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		salt, err := argon2id.GenerateSaltFrom(bytes.NewReader(seed), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if salt != "AQIDBAUGBwgJCgsMDQ4PEA" {
+			t.Fatal("Expected salt to be read from the reader.")
+		}
+	})
+
+	t.Run("ReproducibleKey", func(t *testing.T) {
+		options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+		keys := make([]string, 2)
+
+		for i := range keys {
+			salt, err := argon2id.GenerateSaltFrom(bytes.NewReader(seed), 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if keys[i], err = argon2id.HashPassword("password", salt, options); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if keys[0] != keys[1] {
+			t.Fatal("Expected equal keys.")
+		}
+	})
+
+	t.Run("NilReader", func(t *testing.T) {
+		salt, err := argon2id.GenerateSaltFrom(nil, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(salt) != 22 {
+			t.Fatal("Expected salt of 22 characters.")
+		}
+	})
+
+	t.Run("ShortReader", func(t *testing.T) {
+		if _, err := argon2id.GenerateSaltFrom(bytes.NewReader(seed), 17); err != io.ErrUnexpectedEOF {
+			t.Fatal("Expected io.ErrUnexpectedEOF.")
+		}
+	})
+
+	t.Run("InvalidLength", func(t *testing.T) {
+		if _, err := argon2id.GenerateSaltFrom(bytes.NewReader(seed), 0); err != argon2id.ErrInvalidSaltLength {
+			t.Fatal("Expected ErrInvalidSaltLength.")
+		}
+	})
+}
+
+func TestHashPasswordWithSalt(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	key, err := argon2id.HashPasswordWithSalt("password", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HashPassword uses the encoded salt as is.
+	if saltLen, err := argon2id.SaltLenOf(key); err != nil {
+		t.Fatal(err)
+	} else if saltLen != base64.RawURLEncoding.EncodedLen(16) {
+		t.Fatal("Expected encoded 16 byte salt.")
+	}
+
+	if err := argon2id.VerifyPassword("password", key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHashPasswordGenerateSalt(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, _, err := argon2id.HashPasswordGenerateSalt("", options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("Salt", func(t *testing.T) {
+		key, salt, err := argon2id.HashPasswordGenerateSalt("password", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b, err := argon2id.DecodeBase64String(salt); err != nil {
+			t.Fatal(err)
+		} else if len(b) != 16 {
+			t.Fatal("Expected 16 byte salt.")
+		}
+
+		expected, err := argon2id.HashPassword("password", salt, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key != expected {
+			t.Fatal("Expected key using the returned salt.")
+		}
+	})
+
+	t.Run("SaltLength", func(t *testing.T) {
+		defer func(length int) { argon2id.SaltLength = length }(argon2id.SaltLength)
+		argon2id.SaltLength = 32
+
+		_, salt, err := argon2id.HashPasswordGenerateSalt("password", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b, _ := argon2id.DecodeBase64String(salt); len(b) != 32 {
+			t.Fatal("Expected 32 byte salt.")
+		}
+	})
+}
+
+func TestHashPasswordWithRandomSalt(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		if _, err := argon2id.HashPasswordWithRandomSalt("", options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	t.Run("DistinctSalts", func(t *testing.T) {
+		a, err := argon2id.HashPasswordWithRandomSalt("password", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := argon2id.HashPasswordWithRandomSalt("password", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a == b {
+			t.Fatal("Expected distinct keys.")
+		}
+
+		if err := argon2id.VerifyPassword("password", a); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestVerifyPasswordHexSalt(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+
+	key, err := argon2id.HashPassword("password", "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := strings.Split(key, "$")
+	segments[4] = ""
+	splitKey := strings.Join(segments, "$")
+
+	t.Run("ValidHexSalt", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHexSalt("password", splitKey, "73616c74"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHexSalt("wrong", splitKey, "73616c74"); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("WrongSalt", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHexSalt("password", splitKey, "73616c75"); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("InvalidHexSalt", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHexSalt("password", splitKey, "zz"); err != argon2id.ErrInvalidHexSalt {
+			t.Fatal("Expected ErrInvalidHexSalt.")
+		}
+	})
+
+	t.Run("EmptySalt", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordHexSalt("password", splitKey, ""); err != argon2id.ErrSaltRequired {
+			t.Fatal("Expected ErrSaltRequired.")
+		}
+	})
+}
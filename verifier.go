@@ -0,0 +1,187 @@
+package argon2id
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Verifier verifies passwords against argon2 keys while enforcing additional
+// policies. The zero value behaves like VerifyPassword.
+type Verifier struct {
+	// EnforceMinVerifyTime is the minimum duration of a verification. If a
+	// verification completes faster, successful or not, Verify sleeps for the
+	// remaining time. This keeps fast failures, e.g. for unknown users or
+	// malformed keys, from being distinguishable by their response time.
+	EnforceMinVerifyTime time.Duration
+}
+
+// Verify takes a password and an argon2 key and compares both. It will return
+// an error if they are not equal.
+func (v *Verifier) Verify(password string, key string) error {
+	start := now()
+	defer padDuration(start, v.EnforceMinVerifyTime)
+
+	return VerifyPassword(password, key)
+}
+
+// padDuration sleeps until at least min has passed since start.
+func padDuration(start time.Time, min time.Duration) {
+	if remaining := min - now().Sub(start); remaining > 0 {
+		time.Sleep(remaining)
+	}
+}
+
+// VerifyPasswordBounded takes a password and an argon2 key and compares both
+// while bounding the duration of the verification. argon2 can not be
+// interrupted, so the cost of the key is estimated before running it and
+// ErrVerifyTooExpensive is returned if it exceeds maxTime or the time left
+// until the deadline of ctx. A maxTime of zero disables the limit. Every
+// verification, successful or not, takes at least minTime unless ctx is done
+// earlier.
+func VerifyPasswordBounded(ctx context.Context, password string, key string, minTime time.Duration, maxTime time.Duration) error {
+	start := now()
+	defer padDurationContext(ctx, start, minTime)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	estimate := estimateDuration(p)
+	if maxTime > 0 && estimate > maxTime {
+		return ErrVerifyTooExpensive
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && estimate > deadline.Sub(now()) {
+		return ErrVerifyTooExpensive
+	}
+
+	return verifyKey(password, p, salt, hash)
+}
+
+// padDurationContext works like padDuration but stops sleeping once ctx is
+// done.
+func padDurationContext(ctx context.Context, start time.Time, min time.Duration) {
+	remaining := min - now().Sub(start)
+	if remaining <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// VerifyPasswordLimited works like VerifyPassword but returns
+// ErrExceedsLimits without running argon2 if the Time, Memory, Threads or
+// KeyLen of the key exceed those of limits. Zero fields and nil limits are not
+// limited. It should be used for keys that may be attacker controlled, e.g.
+// keys embedded in tokens, since the options of a key determine the cost of
+// verifying it.
+func VerifyPasswordLimited(password string, key string, limits *Options) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if limits == nil {
+		limits = &Options{}
+	}
+
+	if exceeds(p.Time, limits.Time) ||
+		exceeds(p.Memory, limits.Memory) ||
+		exceeds(uint32(p.Threads), uint32(limits.Threads)) ||
+		exceeds(p.KeyLen, limits.KeyLen) {
+		return ErrExceedsLimits
+	}
+
+	return verifyKey(password, p, salt, hash)
+}
+
+// VerifyPasswordKeyLen works like VerifyPassword but returns ErrKeyLenMismatch
+// without running argon2 if the hash of the key is not exactly keyLen bytes.
+// VerifyPassword derives as many bytes as the stored hash has, so a truncated
+// hash would still verify against a shorter derived key. It can be used to
+// enforce the KeyLen of a policy on stored keys.
+func VerifyPasswordKeyLen(password string, key string, keyLen uint32) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if p.KeyLen != keyLen {
+		return ErrKeyLenMismatch
+	}
+
+	return verifyKey(password, p, salt, hash)
+}
+
+// exceeds reports whether v exceeds the limit. A zero limit is never exceeded.
+func exceeds(v uint32, limit uint32) bool {
+	return limit != 0 && v > limit
+}
+
+// VerifyPasswordConstantTime works like VerifyPassword but also runs argon2
+// using DefaultOptions if the password is empty or the key can not be parsed,
+// so the response time does not reveal whether a key is well-formed. The
+// tradeoff is that every malformed key costs as much as a verification, which
+// makes requests with garbage keys as expensive to serve as real ones. Keys
+// with options more expensive than DefaultOptions still take longer.
+// Passwords longer than MaxPasswordLength return ErrPasswordTooLong before any
+// argon2 is run.
+func VerifyPasswordConstantTime(password string, key string) error {
+	if err := checkPasswordLength(len(password)); err != nil {
+		return err
+	}
+
+	var (
+		p          *Options
+		salt, hash []byte
+		err        = ErrPasswordRequired
+	)
+
+	if password != "" {
+		p, salt, hash, err = parseKey(key)
+	}
+
+	if err != nil {
+		argon2.IDKey(
+			[]byte(password), []byte("dummysalt"),
+			DefaultOptions.Time, DefaultOptions.Memory, DefaultOptions.Threads, DefaultOptions.KeyLen,
+		)
+
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	return verifyKey(password, p, salt, hash)
+}
@@ -0,0 +1,232 @@
+package argon2id_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestVerifier(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	t.Run("ZeroValue", func(t *testing.T) {
+		v := &argon2id.Verifier{}
+
+		if err := v.Verify("password", key); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Verify("password1", key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("EnforceMinVerifyTime", func(t *testing.T) {
+		floor := 300 * time.Millisecond
+		v := &argon2id.Verifier{EnforceMinVerifyTime: floor}
+
+		for _, c := range []struct {
+			name     string
+			password string
+			key      string
+			err      error
+		}{
+			{"EmptyPassword", "", key, argon2id.ErrPasswordRequired},
+			{"EmptyKey", "password", "", argon2id.ErrArgon2KeyRequired},
+			{"MalformedKey", "password", "$argon2id$v=19", argon2id.ErrInvalidKeyLength},
+			{"InvalidPassword", "password1", key, argon2id.ErrHashNotEqualPassword},
+			{"ValidPassword", "password", key, nil},
+		} {
+			start := time.Now()
+			err := v.Verify(c.password, c.key)
+			elapsed := time.Since(start)
+
+			if err != c.err {
+				t.Fatalf("%s: Expected %v, got %v.", c.name, c.err, err)
+			}
+
+			if elapsed < floor {
+				t.Fatalf("%s: Expected at least %v, got %v.", c.name, floor, elapsed)
+			}
+		}
+	})
+}
+
+func TestVerifyPasswordBounded(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+	expensive := "$argon2id$v=19$m=4194304,t=64,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+	floor := 100 * time.Millisecond
+
+	t.Run("Valid", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordBounded(context.Background(), "password", key, 0, time.Second); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("TooExpensive", func(t *testing.T) {
+		start := time.Now()
+		err := argon2id.VerifyPasswordBounded(context.Background(), "password", expensive, floor, time.Second)
+
+		if err != argon2id.ErrVerifyTooExpensive {
+			t.Fatal("Expected ErrVerifyTooExpensive.")
+		}
+
+		if time.Since(start) < floor {
+			t.Fatal("Expected verification to be padded.")
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := argon2id.VerifyPasswordBounded(ctx, "password", expensive, 0, 0); err != argon2id.ErrVerifyTooExpensive {
+			t.Fatal("Expected ErrVerifyTooExpensive.")
+		}
+	})
+
+	t.Run("PaddedFastFail", func(t *testing.T) {
+		start := time.Now()
+		err := argon2id.VerifyPasswordBounded(context.Background(), "password", "$argon2id$v=19", floor, time.Second)
+
+		if err != argon2id.ErrInvalidKeyLength {
+			t.Fatal("Expected ErrInvalidKeyLength.")
+		}
+
+		if time.Since(start) < floor {
+			t.Fatal("Expected verification to be padded.")
+		}
+	})
+
+	t.Run("PaddedMismatch", func(t *testing.T) {
+		start := time.Now()
+		err := argon2id.VerifyPasswordBounded(context.Background(), "wrong", key, floor, time.Second)
+
+		if err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+
+		if time.Since(start) < floor {
+			t.Fatal("Expected verification to be padded.")
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := argon2id.VerifyPasswordBounded(ctx, "password", key, floor, time.Second); err != context.Canceled {
+			t.Fatal("Expected context.Canceled.")
+		}
+	})
+}
+
+func TestVerifyPasswordConstantTime(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	for _, c := range []struct {
+		name     string
+		password string
+		key      string
+		err      error
+	}{
+		{"EmptyPassword", "", key, argon2id.ErrPasswordRequired},
+		{"EmptyKey", "password", "", argon2id.ErrArgon2KeyRequired},
+		{"MalformedKey", "password", "$argon2id$v=19", argon2id.ErrInvalidKeyLength},
+		{"InvalidPassword", "password1", key, argon2id.ErrHashNotEqualPassword},
+		{"ValidPassword", "password", key, nil},
+	} {
+		if err := argon2id.VerifyPasswordConstantTime(c.password, c.key); err != c.err {
+			t.Fatalf("%s: Expected %v, got %v.", c.name, c.err, err)
+		}
+	}
+
+	t.Run("Timing", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping timing measurement in short mode.")
+		}
+
+		malformed, valid := compareDurations(1,
+			func() { argon2id.VerifyPasswordConstantTime("password", "$argon2id$v=19") },
+			func() { argon2id.VerifyPasswordConstantTime("password1", key) },
+		)
+
+		if 3*malformed < valid {
+			t.Fatalf("Expected malformed key to take about as long as a valid one, got %v and %v.", malformed, valid)
+		}
+	})
+}
+
+func TestVerifyPasswordLimited(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$OWwmnKFemKE2ILjM60j1so1oRXDFJYqvOiYlZTByvuU"
+
+	for _, c := range []struct {
+		name   string
+		limits argon2id.Options
+		err    error
+	}{
+		{"WithinLimits", *argon2id.DefaultOptions, nil},
+		{"NoLimits", argon2id.Options{}, nil},
+		{"Memory", argon2id.Options{Memory: 32 * 1024}, argon2id.ErrExceedsLimits},
+		{"Threads", argon2id.Options{Threads: 2}, argon2id.ErrExceedsLimits},
+		{"KeyLen", argon2id.Options{KeyLen: 16}, argon2id.ErrExceedsLimits},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			if err := argon2id.VerifyPasswordLimited("password", key, &c.limits); err != c.err {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+		})
+	}
+
+	t.Run("TimeLimit", func(t *testing.T) {
+		expensive := strings.Replace(key, "t=1", "t=1000", 1)
+
+		start := time.Now()
+		if err := argon2id.VerifyPasswordLimited("password", expensive, &argon2id.Options{Time: 3}); err != argon2id.ErrExceedsLimits {
+			t.Fatal("Expected ErrExceedsLimits.")
+		}
+
+		if time.Since(start) > time.Second {
+			t.Fatal("Expected argon2 not to run.")
+		}
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPasswordLimited("password1", key, argon2id.DefaultOptions); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+}
+
+func TestVerifyPasswordKeyLen(t *testing.T) {
+	// password:salt
+	key := "$argon2id$v=19$m=8,t=1,p=1$c2FsdA$YJlbY_v2Y0dxDBpDeQe1FKQN_bGUq0pAw8IzhL7BMHA"
+
+	for _, c := range []struct {
+		name     string
+		password string
+		keyLen   uint32
+		err      error
+	}{
+		{"Match", "password", 32, nil},
+		{"WrongPassword", "password1", 32, argon2id.ErrHashNotEqualPassword},
+		{"Shorter", "password", 16, argon2id.ErrKeyLenMismatch},
+		{"Longer", "password", 64, argon2id.ErrKeyLenMismatch},
+		{"EmptyPassword", "", 32, argon2id.ErrPasswordRequired},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			if err := argon2id.VerifyPasswordKeyLen(c.password, key, c.keyLen); err != c.err {
+				t.Fatalf("Expected %v, got %v.", c.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package argon2id
+
+import (
+	"runtime"
+)
+
+// wipeHook is called with every buffer after it was wiped. It is only set by
+// tests.
+var wipeHook func(b []byte)
+
+// wipe overwrites the given buffers with zeros. runtime.KeepAlive keeps the
+// buffers reachable until the writes are done, so they can not be optimized
+// away.
+func wipe(buffers ...[]byte) {
+	for _, b := range buffers {
+		for i := range b {
+			b[i] = 0
+		}
+
+		runtime.KeepAlive(b)
+
+		if wipeHook != nil {
+			wipeHook(b)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package argon2id
+
+// layeredSegment is the trailing segment of keys created by WrapExistingHash.
+const layeredSegment = layeredPrefix + "legacy"
+
+// WrapExistingHash returns an argon2 key of a hash created by another KDF,
+// e.g. a bcrypt or scrypt hash, using it as the password. Stored legacy hashes
+// can be upgraded in place this way without knowing the passwords. The key is
+// marked with a trailing "$layered=legacy" segment, so VerifyPassword and
+// every other verify function return ErrWrappedKey instead of comparing the
+// password directly. To verify a login the caller recomputes the legacy hash
+// from the password, using the salt and cost of the original hash, and passes
+// it to VerifyExistingHash. Once it succeeds the password can be rehashed
+// using HashPassword.
+func WrapExistingHash(existingHash []byte, salt string, options *Options) (string, error) {
+	if len(existingHash) == 0 {
+		return "", ErrPasswordRequired
+	}
+
+	_, key, err := deriveKeyBytes(existingHash, []byte(salt), options, layeredSegment)
+
+	return key, err
+}
+
+// VerifyExistingHash takes a hash created by another KDF and an argon2 key
+// created by WrapExistingHash and compares both. It will return an error if
+// they are not equal. Keys not created by WrapExistingHash never match.
+func VerifyExistingHash(existingHash []byte, key string) error {
+	if len(existingHash) == 0 {
+		return ErrPasswordRequired
+	}
+
+	p, salt, hash, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	defer wipe(salt, hash)
+
+	if !p.wrapped {
+		return ErrHashNotEqualPassword
+	}
+
+	p.wrapped = false
+
+	return verifyKeyBytes(existingHash, p, salt, hash)
+}
+
+// containsSegment reports whether the given segments contain the segment.
+func containsSegment(segments []string, segment string) bool {
+	for _, s := range segments {
+		if s == segment {
+			return true
+		}
+	}
+
+	return false
+}
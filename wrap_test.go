@@ -0,0 +1,159 @@
+package argon2id_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dhenkes/argon2id"
+)
+
+func TestWrapExistingHash(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+	legacy := []byte("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy")
+
+	t.Run("EmptyHash", func(t *testing.T) {
+		if _, err := argon2id.WrapExistingHash(nil, "salt", options); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+
+		if err := argon2id.VerifyExistingHash(nil, "key"); err != argon2id.ErrPasswordRequired {
+			t.Fatal("Expected ErrPasswordRequired.")
+		}
+	})
+
+	key, err := argon2id.WrapExistingHash(legacy, "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Marker", func(t *testing.T) {
+		if !strings.HasSuffix(key, "$layered=legacy") {
+			t.Fatal("Expected layered marker.")
+		}
+	})
+
+	t.Run("VerifyExistingHash", func(t *testing.T) {
+		if err := argon2id.VerifyExistingHash(legacy, key); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyExistingHash([]byte("other"), key); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("VerifyPassword", func(t *testing.T) {
+		if err := argon2id.VerifyPassword(string(legacy), key); err != argon2id.ErrWrappedKey {
+			t.Fatal("Expected ErrWrappedKey.")
+		}
+	})
+
+	t.Run("NotWrapped", func(t *testing.T) {
+		plain, err := argon2id.HashPassword(string(legacy), "salt", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyExistingHash(legacy, plain); err != argon2id.ErrHashNotEqualPassword {
+			t.Fatal("Expected ErrHashNotEqualPassword.")
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		withChecksum := *options
+		withChecksum.Checksum = true
+		withChecksum.Nonce = true
+
+		key, err := argon2id.WrapExistingHash(legacy, "salt", &withChecksum)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := argon2id.VerifyExistingHash(legacy, key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestWrappedKeyRejected(t *testing.T) {
+	options := &argon2id.Options{Time: 1, Memory: 8, Threads: 1, KeyLen: 32}
+	legacy := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+	stronger := &argon2id.Options{Time: 2, Memory: 8, Threads: 1, KeyLen: 32}
+
+	key, err := argon2id.WrapExistingHash([]byte(legacy), "salt", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, verify := range map[string]func() error{
+		"VerifyPassword": func() error { return argon2id.VerifyPassword(legacy, key) },
+		"VerifyPasswordBytes": func() error {
+			return argon2id.VerifyPasswordBytes([]byte(legacy), key)
+		},
+		"VerifyPasswordConstantTime": func() error { return argon2id.VerifyPasswordConstantTime(legacy, key) },
+		"VerifyPasswordLimited":      func() error { return argon2id.VerifyPasswordLimited(legacy, key, nil) },
+		"VerifyPasswordKeyLen":       func() error { return argon2id.VerifyPasswordKeyLen(legacy, key, 32) },
+		"VerifyPasswordHexSalt":      func() error { return argon2id.VerifyPasswordHexSalt(legacy, key, "73616c74") },
+		"VerifyOrRehash":             func() error { return argon2id.VerifyOrRehash(legacy, key, options) },
+		"VerifyPasswordBounded": func() error {
+			return argon2id.VerifyPasswordBounded(context.Background(), legacy, key, 0, 0)
+		},
+		"VerifyPasswordVersions": func() error {
+			return argon2id.VerifyPasswordVersions(legacy, key, []int{argon2id.Version})
+		},
+		"VerifyPasswordProfiled": func() error {
+			_, err := argon2id.VerifyPasswordProfiled(legacy, key)
+			return err
+		},
+		"VerifyAndRehash": func() error {
+			_, err := argon2id.VerifyAndRehash(legacy, key, stronger)
+			return err
+		},
+		"VerifyAndUpgradeBytes": func() error {
+			_, _, _, err := argon2id.VerifyAndUpgradeBytes([]byte(legacy), key, stronger)
+			return err
+		},
+		"VerifyPasswordWithPeppers": func() error {
+			_, err := argon2id.VerifyPasswordWithPeppers(legacy, key, [][]byte{nil})
+			return err
+		},
+		"VerifyThenDerive": func() error {
+			_, err := argon2id.VerifyThenDerive(legacy, key, "info", 32)
+			return err
+		},
+		"VerifyPasswordHMAC": func() error {
+			return argon2id.VerifyPasswordHMAC(legacy, key, []byte("serverkey"))
+		},
+		"KeyVerify": func() error {
+			k, err := argon2id.DecodeKey(key)
+			if err != nil {
+				return err
+			}
+
+			return k.Verify(legacy)
+		},
+	} {
+		if err := verify(); !errors.Is(err, argon2id.ErrWrappedKey) {
+			t.Fatalf("%s: Expected ErrWrappedKey, got %v.", name, err)
+		}
+	}
+
+	t.Run("CandidateTester", func(t *testing.T) {
+		c, err := argon2id.NewCandidateTester(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.Test(legacy) {
+			t.Fatal("Did not expect legacy hash to match.")
+		}
+	})
+
+	t.Run("VerifyExistingHash", func(t *testing.T) {
+		if err := argon2id.VerifyExistingHash([]byte(legacy), key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}